@@ -56,6 +56,8 @@ func (p *Program) String() string {
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	// Type is the annotated type of the binding, nil if there is none
+	Type  Type
 	Value Expression
 }
 
@@ -64,12 +66,22 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+// IsConst reports whether the binding was declared with const and
+// therefore cannot be reassigned.
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.CONST
+}
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral())
 	out.WriteRune(' ')
 	out.WriteString(ls.Name.String())
+	if ls.Type != nil {
+		out.WriteString(": ")
+		out.WriteString(ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -80,9 +92,64 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+type AssignExpression struct {
+	Token  token.Token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	return out.String()
+}
+
+type ImportStatement struct {
+	Token token.Token
+	Path  string
+	// Name is the identifier the module is bound to, either given with as
+	// or derived from the last element of Path
+	Name  *Identifier
+	Alias bool
+}
+
+func (is *ImportStatement) statementNode() {}
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral())
+	out.WriteString(" \"")
+	out.WriteString(is.Path)
+	out.WriteRune('"')
+
+	if is.Alias {
+		out.WriteString(" as ")
+		out.WriteString(is.Name.String())
+	}
+
+	out.WriteRune(';')
+	return out.String()
+}
+
 type Identifier struct {
 	Token token.Token
 	Value string
+	// Resolved is set once Depth and Slot locate the variable the
+	// identifier refers to: in the scope Depth levels out, at index Slot of
+	// the scope or by name if Slot is negative
+	Resolved    bool
+	Depth, Slot int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -143,6 +210,34 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode() {}
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *StringLiteral) String() string {
+	return sl.Token.Literal
+}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -229,6 +324,9 @@ func (ie *IfExpression) String() string {
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	// Names lists the variables of the scope the block opens by slot, once
+	// resolved
+	Names []string
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -246,7 +344,15 @@ func (bs *BlockStatement) String() string {
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParamTypes holds the annotated type of each parameter, nil entries
+	// for parameters without one
+	ParamTypes []Type
+	// Result is the annotated result type, nil if there is none
+	Result Type
+	Body   *BlockStatement
+	// Name is the identifier the literal is bound to by a let statement,
+	// empty for anonymous functions
+	Name string
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -260,12 +366,20 @@ func (fl *FunctionLiteral) String() string {
 	params := make([]string, len(fl.Parameters))
 	for i, p := range fl.Parameters {
 		params[i] = p.String()
+		if i < len(fl.ParamTypes) && fl.ParamTypes[i] != nil {
+			params[i] += ": " + fl.ParamTypes[i].String()
+		}
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteRune('(')
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteRune(')')
+	if fl.Result != nil {
+		out.WriteString(": ")
+		out.WriteString(fl.Result.String())
+		out.WriteRune(' ')
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -295,3 +409,129 @@ func (ce *CallExpression) String() string {
 	out.WriteRune(')')
 	return out.String()
 }
+
+type TryExpression struct {
+	Token   token.Token
+	Block   *BlockStatement
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (te *TryExpression) expressionNode() {}
+func (te *TryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.Param != nil {
+			out.WriteRune('(')
+			out.WriteString(te.Param.String())
+			out.WriteString(") ")
+		}
+		out.WriteString(te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+	return out.String()
+}
+
+type ArrayLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := make([]string, len(al.Elements))
+	for i, el := range al.Elements {
+		elements[i] = el.String()
+	}
+
+	out.WriteRune('[')
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteRune(']')
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token token.Token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode() {}
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteRune('(')
+	out.WriteString(ie.Left.String())
+	out.WriteRune('[')
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+type DotExpression struct {
+	Token token.Token
+	Left  Expression
+	Name  *Identifier
+}
+
+func (de *DotExpression) expressionNode() {}
+func (de *DotExpression) TokenLiteral() string {
+	return de.Token.Literal
+}
+
+func (de *DotExpression) String() string {
+	return de.Left.String() + "." + de.Name.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	Token token.Token
+	// Pairs are kept in source order, which is also evaluation order
+	Pairs []HashPair
+}
+
+func (hl *HashLiteral) expressionNode() {}
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := make([]string, len(hl.Pairs))
+	for i, pair := range hl.Pairs {
+		pairs[i] = pair.Key.String() + ": " + pair.Value.String()
+	}
+
+	out.WriteRune('{')
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteRune('}')
+	return out.String()
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"monkey/token"
+	"strings"
 	"testing"
 )
 
@@ -26,3 +27,51 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String wrong, got=%q", program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: name}, Value: name}
+	}
+
+	// let f = fn(x) { g(x, y) }; f.z
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Name: ident("f"),
+				Value: &FunctionLiteral{
+					Parameters: []*Identifier{ident("x")},
+					Body: &BlockStatement{Statements: []Statement{
+						&ExpressionStatement{Expression: &CallExpression{
+							Func: ident("g"),
+							Args: []Expression{ident("x"), ident("y")},
+						}},
+					}},
+				},
+			},
+			&ExpressionStatement{Expression: &DotExpression{Left: ident("f"), Name: ident("z")}},
+		},
+	}
+
+	var names []string
+	Inspect(program, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			names = append(names, ident.Value)
+		}
+		return true
+	})
+	if got := strings.Join(names, " "); got != "f x g x y f z" {
+		t.Errorf("identifiers visited in wrong order, got=%q", got)
+	}
+
+	names = nil
+	Inspect(program, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			names = append(names, ident.Value)
+		}
+		_, ok := node.(*FunctionLiteral)
+		return !ok
+	})
+	if got := strings.Join(names, " "); got != "f f z" {
+		t.Errorf("function body should be skipped, got=%q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+	"strings"
+)
+
+// Type is a type annotation. Annotations are optional and only read by
+// static checkers, the evaluator ignores them.
+type Type interface {
+	Node
+	typeNode()
+}
+
+// NamedType is a type referred to by name, such as int or string.
+type NamedType struct {
+	Token token.Token
+	Name  string
+}
+
+func (nt *NamedType) typeNode() {}
+func (nt *NamedType) TokenLiteral() string {
+	return nt.Token.Literal
+}
+func (nt *NamedType) String() string {
+	return nt.Name
+}
+
+// ArrayType is written [T].
+type ArrayType struct {
+	Token   token.Token
+	Element Type
+}
+
+func (at *ArrayType) typeNode() {}
+func (at *ArrayType) TokenLiteral() string {
+	return at.Token.Literal
+}
+func (at *ArrayType) String() string {
+	return "[" + at.Element.String() + "]"
+}
+
+// HashType is written {K: V}.
+type HashType struct {
+	Token token.Token
+	Key   Type
+	Value Type
+}
+
+func (ht *HashType) typeNode() {}
+func (ht *HashType) TokenLiteral() string {
+	return ht.Token.Literal
+}
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
+
+// FunctionType is written fn(A, B): R, the result type being optional.
+type FunctionType struct {
+	Token  token.Token
+	Params []Type
+	Result Type
+}
+
+func (ft *FunctionType) typeNode() {}
+func (ft *FunctionType) TokenLiteral() string {
+	return ft.Token.Literal
+}
+func (ft *FunctionType) String() string {
+	var out bytes.Buffer
+
+	params := make([]string, len(ft.Params))
+	for i, p := range ft.Params {
+		params[i] = p.String()
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteRune(')')
+	if ft.Result != nil {
+		out.WriteString(": ")
+		out.WriteString(ft.Result.String())
+	}
+	return out.String()
+}
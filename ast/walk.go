@@ -0,0 +1,87 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth-first order, calling
+// f for every node. If f returns false, the children of that node are
+// skipped. Type annotations are not visited.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *LetStatement:
+		Inspect(n.Name, f)
+		inspectExpr(n.Value, f)
+	case *ImportStatement:
+		Inspect(n.Name, f)
+	case *ReturnStatement:
+		inspectExpr(n.ReturnValue, f)
+	case *ExpressionStatement:
+		inspectExpr(n.Expression, f)
+	case *AssignExpression:
+		inspectExpr(n.Target, f)
+		inspectExpr(n.Value, f)
+	case *PrefixExpression:
+		inspectExpr(n.Right, f)
+	case *InfixExpression:
+		inspectExpr(n.Left, f)
+		inspectExpr(n.Right, f)
+	case *IfExpression:
+		inspectExpr(n.Condition, f)
+		Inspect(n.Consequence, f)
+		if n.Alternative != nil {
+			Inspect(n.Alternative, f)
+		}
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			Inspect(param, f)
+		}
+		Inspect(n.Body, f)
+	case *CallExpression:
+		inspectExpr(n.Func, f)
+		for _, arg := range n.Args {
+			inspectExpr(arg, f)
+		}
+	case *TryExpression:
+		Inspect(n.Block, f)
+		if n.Param != nil {
+			Inspect(n.Param, f)
+		}
+		if n.Catch != nil {
+			Inspect(n.Catch, f)
+		}
+		if n.Finally != nil {
+			Inspect(n.Finally, f)
+		}
+	case *ArrayLiteral:
+		for _, el := range n.Elements {
+			inspectExpr(el, f)
+		}
+	case *IndexExpression:
+		inspectExpr(n.Left, f)
+		inspectExpr(n.Index, f)
+	case *DotExpression:
+		inspectExpr(n.Left, f)
+		Inspect(n.Name, f)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			inspectExpr(pair.Key, f)
+			inspectExpr(pair.Value, f)
+		}
+	}
+}
+
+// inspectExpr skips expressions that are absent.
+func inspectExpr(expr Expression, f func(Node) bool) {
+	if expr != nil {
+		Inspect(expr, f)
+	}
+}
@@ -0,0 +1,319 @@
+// Package check finds mistakes before a program runs. Check reports the
+// misuse of names: undefined names, names used before their definition,
+// unused variables and shadowed declarations. Types reports the operations
+// on values of the wrong type, using optional type annotations.
+package check
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/eval"
+	"monkey/token"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SEVERITY_ERROR Severity = iota
+	SEVERITY_WARNING
+)
+
+func (s Severity) String() string {
+	if s == SEVERITY_WARNING {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem found at a position in the program. Errors are
+// certain to fail at runtime if the code is reached, warnings are likely
+// mistakes.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Msg      string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Msg)
+}
+
+// Check resolves the names used in program and returns the problems found,
+// ordered by position. Besides the builtins, names in globals are taken to
+// be defined by the host.
+func Check(program *ast.Program, globals ...string) []Diagnostic {
+	c := &checker{predeclared: make(map[string]string)}
+	for _, name := range eval.BuiltinNames() {
+		c.predeclared[name] = "builtin"
+	}
+	for _, name := range globals {
+		c.predeclared[name] = "global"
+	}
+
+	c.openScope(program.Statements)
+	c.checkStmts(program.Statements)
+	// top-level bindings are visible to the host and to importers, so they
+	// are never reported as unused
+	c.scope = nil
+
+	Sort(c.diags)
+	return c.diags
+}
+
+type bindingKind int
+
+const (
+	BINDING_LET bindingKind = iota
+	BINDING_CONST
+	BINDING_IMPORT
+	BINDING_PARAM
+)
+
+type binding struct {
+	ident *ast.Identifier
+	kind  bindingKind
+	used  bool
+}
+
+type scope struct {
+	outer *scope
+	// function counts the function literals the scope is nested in
+	function int
+	// defined holds the names bound so far in program order
+	defined map[string]*binding
+	// declared holds where every let in the scope first binds each name,
+	// whether it was reached yet or not
+	declared map[string]token.Position
+	// early holds the declared names used by functions before the
+	// declaration is reached
+	early map[string]bool
+}
+
+type checker struct {
+	scope       *scope
+	function    int
+	predeclared map[string]string
+	diags       []Diagnostic
+}
+
+func (c *checker) report(pos token.Position, severity Severity, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Severity: severity, Msg: fmt.Sprintf(format, args...)})
+}
+
+// openScope enters a scope for stmts, collecting the names they declare.
+func (c *checker) openScope(stmts []ast.Statement) {
+	s := &scope{
+		outer:    c.scope,
+		function: c.function,
+		defined:  make(map[string]*binding),
+		declared: make(map[string]token.Position),
+		early:    make(map[string]bool),
+	}
+
+	for _, stmt := range stmts {
+		var ident *ast.Identifier
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			ident = stmt.Name
+		case *ast.ImportStatement:
+			ident = stmt.Name
+		default:
+			continue
+		}
+		if _, ok := s.declared[ident.Value]; !ok {
+			s.declared[ident.Value] = ident.Token.Pos
+		}
+	}
+	c.scope = s
+}
+
+// closeScope leaves the current scope, reporting the local variables that
+// were never used.
+func (c *checker) closeScope() {
+	for name, b := range c.scope.defined {
+		if !b.used && b.kind != BINDING_PARAM && !strings.HasPrefix(name, "_") {
+			c.report(b.ident.Token.Pos, SEVERITY_WARNING, "%s declared and not used", name)
+		}
+	}
+	c.scope = c.scope.outer
+}
+
+// block checks stmts in a scope of their own, binding params first.
+func (c *checker) block(stmts []ast.Statement, params ...*ast.Identifier) {
+	c.openScope(stmts)
+	for _, param := range params {
+		if prev, ok := c.scope.defined[param.Value]; ok {
+			c.report(param.Token.Pos, SEVERITY_ERROR, "duplicate parameter %s, first at %s",
+				param.Value, prev.ident.Token.Pos)
+			continue
+		}
+		c.declare(param, BINDING_PARAM)
+	}
+	c.checkStmts(stmts)
+	c.closeScope()
+}
+
+func (c *checker) declare(ident *ast.Identifier, kind bindingKind) {
+	name := ident.Value
+
+	if prev, ok := c.scope.defined[name]; ok {
+		if prev.kind == BINDING_CONST || prev.kind == BINDING_IMPORT {
+			c.report(ident.Token.Pos, SEVERITY_ERROR, "cannot redeclare constant %s, declared at %s",
+				name, prev.ident.Token.Pos)
+			return
+		}
+		// a redeclaration replaces the binding, uses of the old one count
+		c.scope.defined[name] = &binding{ident: ident, kind: kind, used: prev.used}
+		return
+	}
+
+	if shadowed, ok := c.lookupOuter(name); ok {
+		c.report(ident.Token.Pos, SEVERITY_WARNING, "%s shadows declaration at %s",
+			name, shadowed.ident.Token.Pos)
+	} else if what, ok := c.predeclared[name]; ok {
+		c.report(ident.Token.Pos, SEVERITY_WARNING, "%s shadows %s %s", name, what, name)
+	}
+	c.scope.defined[name] = &binding{ident: ident, kind: kind, used: c.scope.early[name]}
+}
+
+// lookupOuter finds the binding name refers to in the scopes enclosing the
+// current one.
+func (c *checker) lookupOuter(name string) (*binding, bool) {
+	for s := c.scope.outer; s != nil; s = s.outer {
+		if b, ok := s.defined[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// resolve finds the binding ident refers to, reporting it if there is
+// none. A name a scope declares further down resolves if the use is inside
+// a function, which cannot run before the declaration is reached.
+func (c *checker) resolve(ident *ast.Identifier) *binding {
+	var later *token.Position
+
+	for s := c.scope; s != nil; s = s.outer {
+		if b, ok := s.defined[ident.Value]; ok {
+			return b
+		}
+		if pos, ok := s.declared[ident.Value]; ok && later == nil {
+			if s.function < c.function {
+				s.early[ident.Value] = true
+				return nil
+			}
+			later = &pos
+		}
+	}
+
+	switch {
+	case c.predeclared[ident.Value] != "":
+	case later != nil:
+		c.report(ident.Token.Pos, SEVERITY_ERROR, "%s used before its definition at %s",
+			ident.Value, *later)
+	default:
+		c.report(ident.Token.Pos, SEVERITY_ERROR, "undefined: %s", ident.Value)
+	}
+	return nil
+}
+
+func (c *checker) checkStmts(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		c.checkStmt(stmt)
+	}
+}
+
+func (c *checker) checkStmt(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		c.checkExpr(stmt.Value)
+		kind := BINDING_LET
+		if stmt.IsConst() {
+			kind = BINDING_CONST
+		}
+		c.declare(stmt.Name, kind)
+	case *ast.ImportStatement:
+		c.declare(stmt.Name, BINDING_IMPORT)
+	case *ast.ReturnStatement:
+		c.checkExpr(stmt.ReturnValue)
+	case *ast.ExpressionStatement:
+		c.checkExpr(stmt.Expression)
+	case *ast.BlockStatement:
+		c.block(stmt.Statements)
+	}
+}
+
+func (c *checker) checkExpr(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if b := c.resolve(expr); b != nil {
+			b.used = true
+		}
+	case *ast.AssignExpression:
+		c.checkExpr(expr.Value)
+		c.checkAssignTarget(expr.Target)
+	case *ast.PrefixExpression:
+		c.checkExpr(expr.Right)
+	case *ast.InfixExpression:
+		c.checkExpr(expr.Left)
+		c.checkExpr(expr.Right)
+	case *ast.IfExpression:
+		c.checkExpr(expr.Condition)
+		c.block(expr.Consequence.Statements)
+		if expr.Alternative != nil {
+			c.block(expr.Alternative.Statements)
+		}
+	case *ast.FunctionLiteral:
+		c.function++
+		c.block(expr.Body.Statements, expr.Parameters...)
+		c.function--
+	case *ast.CallExpression:
+		c.checkExpr(expr.Func)
+		for _, arg := range expr.Args {
+			c.checkExpr(arg)
+		}
+	case *ast.TryExpression:
+		c.block(expr.Block.Statements)
+		if expr.Catch != nil {
+			if expr.Param != nil {
+				c.block(expr.Catch.Statements, expr.Param)
+			} else {
+				c.block(expr.Catch.Statements)
+			}
+		}
+		if expr.Finally != nil {
+			c.block(expr.Finally.Statements)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range expr.Elements {
+			c.checkExpr(el)
+		}
+	case *ast.IndexExpression:
+		c.checkExpr(expr.Left)
+		c.checkExpr(expr.Index)
+	case *ast.DotExpression:
+		c.checkExpr(expr.Left)
+	case *ast.HashLiteral:
+		for _, pair := range expr.Pairs {
+			c.checkExpr(pair.Key)
+			c.checkExpr(pair.Value)
+		}
+	}
+}
+
+// checkAssignTarget resolves the target of an assignment, which does not
+// count as a use of the variable.
+func (c *checker) checkAssignTarget(target ast.Expression) {
+	ident, ok := target.(*ast.Identifier)
+	if !ok {
+		c.checkExpr(target)
+		return
+	}
+
+	b := c.resolve(ident)
+	if b != nil && (b.kind == BINDING_CONST || b.kind == BINDING_IMPORT) {
+		c.report(ident.Token.Pos, SEVERITY_ERROR, "cannot assign to constant %s, declared at %s",
+			ident.Value, b.ident.Token.Pos)
+	}
+}
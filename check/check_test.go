@@ -0,0 +1,93 @@
+package check
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func testCheck(t *testing.T, input string, globals ...string) []string {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	diags := Check(program, globals...)
+	out := make([]string, len(diags))
+	for i, d := range diags {
+		out[i] = d.String()
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`let x = 1; x + 1`, nil},
+		{`puts(map([1], fn(x) { x }))`, nil},
+		{`y`, []string{"1:1: error: undefined: y"}},
+		{`let x = 1; if (x > 0) { prnt(x) }`, []string{"1:25: error: undefined: prnt"}},
+		{`x; let x = 1;`, []string{"1:1: error: x used before its definition at 1:8"}},
+		{`let x = x + 1;`, []string{"1:9: error: x used before its definition at 1:5"}},
+		{`let x = 1; if (true) { x; let x = 2; x }`,
+			[]string{"1:31: warning: x shadows declaration at 1:5"}},
+		{`let f = fn() { g() }; let g = fn() { 1 }; f()`, nil},
+		{`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)`, nil},
+		{`let f = fn() { let a = 1; let b = 2; a }; f()`,
+			[]string{"1:31: warning: b declared and not used"}},
+		{`let f = fn() { let _ignored = 1; 2 }; f()`, nil},
+		{`let f = fn() { let g = fn() { h() }; let h = fn() { 1 }; g() }; f()`, nil},
+		{`let f = fn(a, b) { a }; f(1, 2)`, nil},
+		{`let f = fn(a, a) { a }`, []string{"1:15: error: duplicate parameter a, first at 1:12"}},
+		{`let x = 1; let f = fn(x) { x }; f(x)`,
+			[]string{"1:23: warning: x shadows declaration at 1:5"}},
+		{`let map = fn(a, f) { a }; map`, []string{"1:5: warning: map shadows builtin map"}},
+		{`let f = fn() { let x = 1; x = 2; }; f()`,
+			[]string{"1:20: warning: x declared and not used"}},
+		{`z = 1`, []string{"1:1: error: undefined: z"}},
+		{`const c = 1; c = 2`, []string{"1:14: error: cannot assign to constant c, declared at 1:7"}},
+		{`const c = 1; let c = 2`, []string{"1:18: error: cannot redeclare constant c, declared at 1:7"}},
+		{`let x = 1; let x = 2; x`, nil},
+		{`import "strings"; strings.upper("a")`, nil},
+		{`import "strings" as s; strings.upper("a")`, []string{"1:24: error: undefined: strings"}},
+		{`let h = {"a": b}; h.a.c`, []string{"1:15: error: undefined: b"}},
+		{`try { throw("x") } catch (e) { 1 } finally { missing }`,
+			[]string{"1:46: error: undefined: missing"}},
+		{`try { let a = 1 } catch { a }`, []string{
+			"1:11: warning: a declared and not used",
+			"1:27: error: undefined: a",
+		}},
+		{`if (true) { let v = 1 } else { v }`, []string{
+			"1:17: warning: v declared and not used",
+			"1:32: error: undefined: v",
+		}},
+		{`let f = fn() { return q; }`, []string{"1:23: error: undefined: q"}},
+	}
+
+	for _, tt := range tests {
+		got := testCheck(t, tt.input)
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: expected %d diagnostics %q, got=%q", tt.input, len(tt.expected), tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: diagnostic %d wrong, expected=%q, got=%q", tt.input, i, tt.expected[i], got[i])
+			}
+		}
+	}
+}
+
+func TestCheckGlobals(t *testing.T) {
+	if got := testCheck(t, `config.debug`, "config"); len(got) != 0 {
+		t.Errorf("host globals should be defined, got=%q", got)
+	}
+	if got := testCheck(t, `config.debug`); len(got) != 1 {
+		t.Errorf("config should be undefined without globals, got=%q", got)
+	}
+}
@@ -0,0 +1,595 @@
+package check
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"slices"
+	"strings"
+)
+
+type typeKind int
+
+const (
+	TYPE_ANY typeKind = iota
+	TYPE_INT
+	TYPE_FLOAT
+	TYPE_STRING
+	TYPE_BOOL
+	TYPE_NULL
+	TYPE_ARRAY
+	TYPE_HASH
+	TYPE_FUNCTION
+)
+
+var typeNames = map[string]typeKind{
+	"any":    TYPE_ANY,
+	"int":    TYPE_INT,
+	"float":  TYPE_FLOAT,
+	"string": TYPE_STRING,
+	"bool":   TYPE_BOOL,
+	"null":   TYPE_NULL,
+}
+
+// staticType is what the checker knows about the values of an expression.
+// Any stands for values whose type is not known statically, it is
+// consistent with every other type.
+type staticType struct {
+	kind typeKind
+	// elem is the element type of an array, or the value type of a hash
+	elem *staticType
+	// key is the key type of a hash
+	key    *staticType
+	params []*staticType
+	result *staticType
+}
+
+var (
+	anyType    = &staticType{kind: TYPE_ANY}
+	intType    = &staticType{kind: TYPE_INT}
+	floatType  = &staticType{kind: TYPE_FLOAT}
+	stringType = &staticType{kind: TYPE_STRING}
+	boolType   = &staticType{kind: TYPE_BOOL}
+	nullType   = &staticType{kind: TYPE_NULL}
+)
+
+// String formats t the way it is written in annotations.
+func (t *staticType) String() string {
+	switch t.kind {
+	case TYPE_ARRAY:
+		return "[" + t.elem.String() + "]"
+	case TYPE_HASH:
+		return "{" + t.key.String() + ": " + t.elem.String() + "}"
+	case TYPE_FUNCTION:
+		params := make([]string, len(t.params))
+		for i, p := range t.params {
+			params[i] = p.String()
+		}
+		return "fn(" + strings.Join(params, ", ") + "): " + t.result.String()
+	case TYPE_INT:
+		return "int"
+	case TYPE_FLOAT:
+		return "float"
+	case TYPE_STRING:
+		return "string"
+	case TYPE_BOOL:
+		return "bool"
+	case TYPE_NULL:
+		return "null"
+	default:
+		return "any"
+	}
+}
+
+// objectName is the name the evaluator gives to values of type t in its
+// error messages.
+func (t *staticType) objectName() string {
+	switch t.kind {
+	case TYPE_INT:
+		return "INTEGER"
+	case TYPE_FLOAT:
+		return "FLOAT"
+	case TYPE_STRING:
+		return "STRING"
+	case TYPE_BOOL:
+		return "BOOLEAN"
+	case TYPE_NULL:
+		return "NULL"
+	case TYPE_ARRAY:
+		return "ARRAY"
+	case TYPE_HASH:
+		return "HASH"
+	case TYPE_FUNCTION:
+		return "FUNCTION"
+	default:
+		return "ANY"
+	}
+}
+
+func (t *staticType) isNumber() bool {
+	return t.kind == TYPE_INT || t.kind == TYPE_FLOAT
+}
+
+// consistent reports whether values of type t can be used where type u is
+// expected, which is the case unless both are known and differ.
+func consistent(t, u *staticType) bool {
+	if t.kind == TYPE_ANY || u.kind == TYPE_ANY {
+		return true
+	}
+	if t.kind != u.kind {
+		return false
+	}
+
+	switch t.kind {
+	case TYPE_ARRAY:
+		return consistent(t.elem, u.elem)
+	case TYPE_HASH:
+		return consistent(t.key, u.key) && consistent(t.elem, u.elem)
+	case TYPE_FUNCTION:
+		if len(t.params) != len(u.params) {
+			return false
+		}
+		for i := range t.params {
+			if !consistent(u.params[i], t.params[i]) {
+				return false
+			}
+		}
+		return consistent(t.result, u.result)
+	}
+	return true
+}
+
+// join is the type of a value that is either of type t or of type u.
+func join(t, u *staticType) *staticType {
+	if t.kind != u.kind {
+		return anyType
+	}
+
+	switch t.kind {
+	case TYPE_ARRAY:
+		return &staticType{kind: TYPE_ARRAY, elem: join(t.elem, u.elem)}
+	case TYPE_HASH:
+		return &staticType{kind: TYPE_HASH, key: join(t.key, u.key), elem: join(t.elem, u.elem)}
+	case TYPE_FUNCTION:
+		if t.String() != u.String() {
+			return anyType
+		}
+	}
+	return t
+}
+
+type typeVar struct {
+	t *staticType
+	// annotated is set if t was given by an annotation, which assignments
+	// have to respect
+	annotated bool
+}
+
+type typeScope struct {
+	outer *typeScope
+	vars  map[string]*typeVar
+}
+
+// function holds the state of the function literal being checked.
+type function struct {
+	// result is the annotated result type, nil if there is none
+	result  *staticType
+	returns []*staticType
+}
+
+type typeChecker struct {
+	scope    *typeScope
+	function *function
+	// assigned holds the unannotated names that are assigned to somewhere,
+	// their type cannot be inferred from the let alone
+	assigned map[string]bool
+	diags    []Diagnostic
+}
+
+// Types infers the types of the expressions in program, taking type
+// annotations into account, and reports the operations that are certain to
+// fail at runtime, such as 1 + true, and the values that do not match their
+// annotation. Names whose type cannot be inferred, such as builtins and
+// parameters without annotations, are given type any and never reported.
+func Types(program *ast.Program) []Diagnostic {
+	c := &typeChecker{assigned: make(map[string]bool)}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if ident, ok := assign.Target.(*ast.Identifier); ok {
+				c.assigned[ident.Value] = true
+			}
+		}
+		return true
+	})
+
+	c.openScope()
+	c.checkStmts(program.Statements)
+	c.closeScope()
+
+	Sort(c.diags)
+	return c.diags
+}
+
+// Sort orders diags by position, keeping the order of diagnostics at the
+// same position.
+func Sort(diags []Diagnostic) {
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		switch {
+		case a.Pos.Before(b.Pos):
+			return -1
+		case b.Pos.Before(a.Pos):
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+func (c *typeChecker) report(pos token.Position, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Severity: SEVERITY_ERROR, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *typeChecker) openScope() {
+	c.scope = &typeScope{outer: c.scope, vars: make(map[string]*typeVar)}
+}
+
+func (c *typeChecker) closeScope() {
+	c.scope = c.scope.outer
+}
+
+func (c *typeChecker) lookup(name string) *typeVar {
+	for s := c.scope; s != nil; s = s.outer {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// resolveType converts an annotation to the type it denotes, nil
+// annotations denoting any.
+func (c *typeChecker) resolveType(typ ast.Type) *staticType {
+	switch typ := typ.(type) {
+	case *ast.NamedType:
+		kind, ok := typeNames[typ.Name]
+		if !ok {
+			c.report(typ.Token.Pos, "unknown type %s", typ.Name)
+			return anyType
+		}
+		return &staticType{kind: kind}
+	case *ast.ArrayType:
+		return &staticType{kind: TYPE_ARRAY, elem: c.resolveType(typ.Element)}
+	case *ast.HashType:
+		return &staticType{kind: TYPE_HASH, key: c.resolveType(typ.Key), elem: c.resolveType(typ.Value)}
+	case *ast.FunctionType:
+		t := &staticType{kind: TYPE_FUNCTION, params: make([]*staticType, len(typ.Params))}
+		for i, p := range typ.Params {
+			t.params[i] = c.resolveType(p)
+		}
+		t.result = c.resolveType(typ.Result)
+		return t
+	default:
+		return anyType
+	}
+}
+
+// expect reports a value of type t used where type want is required.
+func (c *typeChecker) expect(pos token.Position, t, want *staticType, format string, args ...any) {
+	if !consistent(t, want) {
+		c.report(pos, "cannot use %s as %s in %s", t, want, fmt.Sprintf(format, args...))
+	}
+}
+
+// checkStmts checks stmts and returns the type of the value they produce.
+func (c *typeChecker) checkStmts(stmts []ast.Statement) *staticType {
+	result := nullType
+	for _, stmt := range stmts {
+		result = c.checkStmt(stmt)
+	}
+	return result
+}
+
+func (c *typeChecker) checkBlock(block *ast.BlockStatement) *staticType {
+	c.openScope()
+	defer c.closeScope()
+	return c.checkStmts(block.Statements)
+}
+
+func (c *typeChecker) checkStmt(stmt ast.Statement) *staticType {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		t := c.checkExpr(stmt.Value)
+		v := &typeVar{t: t}
+		if stmt.Type != nil {
+			v = &typeVar{t: c.resolveType(stmt.Type), annotated: true}
+			c.expect(stmt.Name.Token.Pos, t, v.t, "let %s", stmt.Name.Value)
+		} else if c.assigned[stmt.Name.Value] {
+			v.t = anyType
+		}
+		c.scope.vars[stmt.Name.Value] = v
+		// like an assignment, let evaluates to the value it binds
+		return v.t
+	case *ast.ImportStatement:
+		c.scope.vars[stmt.Name.Value] = &typeVar{t: anyType}
+		return nullType
+	case *ast.ReturnStatement:
+		t := c.checkExpr(stmt.ReturnValue)
+		if c.function != nil {
+			if c.function.result != nil {
+				c.expect(stmt.Token.Pos, t, c.function.result, "return")
+			}
+			c.function.returns = append(c.function.returns, t)
+		}
+		// the value of the enclosing block is never used
+		return anyType
+	case *ast.ExpressionStatement:
+		return c.checkExpr(stmt.Expression)
+	case *ast.BlockStatement:
+		return c.checkBlock(stmt)
+	default:
+		return anyType
+	}
+}
+
+func (c *typeChecker) checkExpr(expr ast.Expression) *staticType {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return intType
+	case *ast.FloatLiteral:
+		return floatType
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.Boolean:
+		return boolType
+	case *ast.Identifier:
+		if v := c.lookup(expr.Value); v != nil {
+			return v.t
+		}
+		return anyType
+	case *ast.AssignExpression:
+		return c.checkAssign(expr)
+	case *ast.PrefixExpression:
+		return c.checkPrefix(expr)
+	case *ast.InfixExpression:
+		return c.checkInfix(expr)
+	case *ast.IfExpression:
+		c.checkExpr(expr.Condition)
+		t := c.checkBlock(expr.Consequence)
+		if expr.Alternative == nil {
+			// the value is null when the condition does not hold
+			return join(t, nullType)
+		}
+		return join(t, c.checkBlock(expr.Alternative))
+	case *ast.FunctionLiteral:
+		return c.checkFunction(expr)
+	case *ast.CallExpression:
+		return c.checkCall(expr)
+	case *ast.TryExpression:
+		c.checkBlock(expr.Block)
+		if expr.Catch != nil {
+			c.openScope()
+			if expr.Param != nil {
+				c.scope.vars[expr.Param.Value] = &typeVar{t: anyType}
+			}
+			c.checkStmts(expr.Catch.Statements)
+			c.closeScope()
+		}
+		if expr.Finally != nil {
+			c.checkBlock(expr.Finally)
+		}
+		return anyType
+	case *ast.ArrayLiteral:
+		var elem *staticType
+		for _, el := range expr.Elements {
+			t := c.checkExpr(el)
+			if elem == nil {
+				elem = t
+			} else {
+				elem = join(elem, t)
+			}
+		}
+		if elem == nil {
+			elem = anyType
+		}
+		return &staticType{kind: TYPE_ARRAY, elem: elem}
+	case *ast.HashLiteral:
+		var key, value *staticType
+		for _, pair := range expr.Pairs {
+			k, v := c.checkExpr(pair.Key), c.checkExpr(pair.Value)
+			if key == nil {
+				key, value = k, v
+			} else {
+				key, value = join(key, k), join(value, v)
+			}
+		}
+		if key == nil {
+			key, value = anyType, anyType
+		}
+		return &staticType{kind: TYPE_HASH, key: key, elem: value}
+	case *ast.IndexExpression:
+		return c.checkIndex(expr)
+	case *ast.DotExpression:
+		left := c.checkExpr(expr.Left)
+		if left.kind != TYPE_ANY {
+			c.report(expr.Token.Pos, "member access not supported: %s.%s",
+				left.objectName(), expr.Name.Value)
+		}
+		return anyType
+	default:
+		return anyType
+	}
+}
+
+func (c *typeChecker) checkAssign(expr *ast.AssignExpression) *staticType {
+	t := c.checkExpr(expr.Value)
+
+	ident, ok := expr.Target.(*ast.Identifier)
+	if !ok {
+		c.checkExpr(expr.Target)
+		return t
+	}
+	if v := c.lookup(ident.Value); v != nil && v.annotated {
+		c.expect(ident.Token.Pos, t, v.t, "assignment to %s", ident.Value)
+	}
+	return t
+}
+
+func (c *typeChecker) checkPrefix(expr *ast.PrefixExpression) *staticType {
+	right := c.checkExpr(expr.Right)
+
+	switch {
+	case expr.Operator == "!":
+		return boolType
+	case right.kind == TYPE_ANY || right.isNumber():
+		return right
+	default:
+		c.report(expr.Token.Pos, "unknown operator: %s%s", expr.Operator, right.objectName())
+		return anyType
+	}
+}
+
+// checkInfix mirrors the dispatch of the evaluator on the operand types.
+func (c *typeChecker) checkInfix(expr *ast.InfixExpression) *staticType {
+	left := c.checkExpr(expr.Left)
+	right := c.checkExpr(expr.Right)
+	op := expr.Operator
+
+	comparison := op == "<" || op == ">" || op == "==" || op == "!="
+	switch {
+	case left.kind == TYPE_ANY || right.kind == TYPE_ANY:
+		if comparison {
+			return boolType
+		}
+		return anyType
+	case left.isNumber() && right.isNumber():
+		if comparison {
+			return boolType
+		}
+		if left.kind == TYPE_INT && right.kind == TYPE_INT {
+			return intType
+		}
+		return floatType
+	case left.kind == TYPE_STRING && right.kind == TYPE_STRING && op == "+":
+		return stringType
+	case left.kind != right.kind:
+		c.report(expr.Token.Pos, "type mismatch: %s %s %s",
+			left.objectName(), op, right.objectName())
+		return anyType
+	case op == "==" || op == "!=":
+		return boolType
+	default:
+		c.report(expr.Token.Pos, "unknown operator: %s %s %s",
+			left.objectName(), op, right.objectName())
+		return anyType
+	}
+}
+
+func (c *typeChecker) checkFunction(fn *ast.FunctionLiteral) *staticType {
+	t := &staticType{kind: TYPE_FUNCTION, params: make([]*staticType, len(fn.Parameters))}
+
+	c.openScope()
+	for i, param := range fn.Parameters {
+		v := &typeVar{t: anyType}
+		if i < len(fn.ParamTypes) && fn.ParamTypes[i] != nil {
+			v = &typeVar{t: c.resolveType(fn.ParamTypes[i]), annotated: true}
+		}
+		t.params[i] = v.t
+		c.scope.vars[param.Value] = v
+	}
+
+	outer := c.function
+	c.function = &function{}
+	if fn.Result != nil {
+		c.function.result = c.resolveType(fn.Result)
+	}
+
+	body := c.checkStmts(fn.Body.Statements)
+	if c.function.result != nil {
+		if !endsInReturn(fn.Body) {
+			c.expect(fn.Token.Pos, body, c.function.result, "result of function")
+		}
+		t.result = c.function.result
+	} else {
+		t.result = body
+		if endsInReturn(fn.Body) {
+			t.result = nil
+		}
+		for _, r := range c.function.returns {
+			if t.result == nil {
+				t.result = r
+			} else {
+				t.result = join(t.result, r)
+			}
+		}
+		if t.result == nil {
+			t.result = anyType
+		}
+	}
+
+	c.function = outer
+	c.closeScope()
+	return t
+}
+
+func endsInReturn(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ReturnStatement)
+	return ok
+}
+
+func (c *typeChecker) checkCall(call *ast.CallExpression) *staticType {
+	fn := c.checkExpr(call.Func)
+	args := make([]*staticType, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = c.checkExpr(arg)
+	}
+
+	switch fn.kind {
+	case TYPE_ANY:
+		return anyType
+	case TYPE_FUNCTION:
+	default:
+		c.report(call.Token.Pos, "not a function: %s", fn.objectName())
+		return anyType
+	}
+
+	if len(args) != len(fn.params) {
+		c.report(call.Token.Pos, "wrong number of arguments: want=%d, got=%d",
+			len(fn.params), len(args))
+		return fn.result
+	}
+
+	name := call.Func.String()
+	if _, ok := call.Func.(*ast.Identifier); !ok {
+		name = "function"
+	}
+	for i, arg := range args {
+		c.expect(call.Token.Pos, arg, fn.params[i], "argument %d to %s", i+1, name)
+	}
+	return fn.result
+}
+
+func (c *typeChecker) checkIndex(expr *ast.IndexExpression) *staticType {
+	left := c.checkExpr(expr.Left)
+	index := c.checkExpr(expr.Index)
+
+	switch {
+	case left.kind == TYPE_ANY:
+		return anyType
+	case left.kind == TYPE_ARRAY && (index.kind == TYPE_INT || index.kind == TYPE_ANY):
+		return left.elem
+	case left.kind == TYPE_HASH:
+		switch index.kind {
+		case TYPE_ARRAY, TYPE_HASH, TYPE_FUNCTION:
+			c.report(expr.Token.Pos, "unusable as hash key: %s", index.objectName())
+			return anyType
+		}
+		return left.elem
+	default:
+		c.report(expr.Token.Pos, "index operator not supported: %s[%s]",
+			left.objectName(), index.objectName())
+		return anyType
+	}
+}
@@ -0,0 +1,81 @@
+package check
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"slices"
+	"testing"
+)
+
+func testTypes(t *testing.T, input string) []string {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	diags := Types(program)
+	out := make([]string, len(diags))
+	for i, d := range diags {
+		out[i] = d.String()
+	}
+	return out
+}
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`1 + 2 * 3`, nil},
+		{`1 + true`, []string{"1:3: error: type mismatch: INTEGER + BOOLEAN"}},
+		{`1 + 2.5 > 3`, nil},
+		{`"a" - "b"`, []string{"1:5: error: unknown operator: STRING - STRING"}},
+		{`true + false`, []string{"1:6: error: unknown operator: BOOLEAN + BOOLEAN"}},
+		{`-true`, []string{"1:1: error: unknown operator: -BOOLEAN"}},
+		{`let x = 5; let y = "a"; x + y`, []string{"1:27: error: type mismatch: INTEGER + STRING"}},
+		{`let f = fn(a) { a + 1 }; f(true)`, nil},
+		{`let f = fn(a: int) { a }; f("one")`,
+			[]string{`1:28: error: cannot use string as int in argument 1 to f`}},
+		{`let f = fn(a, b) { a }; f(1)`,
+			[]string{"1:26: error: wrong number of arguments: want=2, got=1"}},
+		{`let f = fn(): string { 1 }`,
+			[]string{"1:9: error: cannot use int as string in result of function"}},
+		{`let f = fn(n: int): int { if (n < 2) { return "small" } n }`,
+			[]string{"1:40: error: cannot use string as int in return"}},
+		{`let x: int = "five"`, []string{"1:5: error: cannot use string as int in let x"}},
+		{`let x: float = 1.5; x = true`,
+			[]string{"1:21: error: cannot use bool as float in assignment to x"}},
+		{`let x = 1; x = "one"; x + 1`, nil},
+		{`let xs: [int] = [1, 2]; xs[0] + "a"`,
+			[]string{"1:31: error: type mismatch: INTEGER + STRING"}},
+		{`let xs = [1, "a"]; xs[0] + true`, nil},
+		{`let h: {string: int} = {"a": "b"}`,
+			[]string{`1:5: error: cannot use {string: string} as {string: int} in let h`}},
+		{`let h = {"a": 1}; h[[1]]`, []string{"1:20: error: unusable as hash key: ARRAY"}},
+		{`"abc"[0]`, []string{"1:6: error: index operator not supported: STRING[INTEGER]"}},
+		{`let x = 5; x(1)`, []string{"1:13: error: not a function: INTEGER"}},
+		{`let x = 5; x.y`, []string{"1:13: error: member access not supported: INTEGER.y"}},
+		{`let apply = fn(f: fn(int): int, x: int): int { f(x) }; apply(fn(s: string) { s }, 1)`,
+			[]string{"1:61: error: cannot use fn(string): string as fn(int): int in argument 1 to apply"}},
+		{`let n = if (true) { 1 } else { 2 }; n + 1`, nil},
+		{`let n = if (true) { 1 } else { "a" }; n + true`, nil},
+		{`let f = fn() { let x = 1 }; f() + 1`, nil},
+		{`let f = fn(): int { let x = 1; }`, nil},
+		{`let f = fn() { let x = "a" }; f() + 1`, []string{"1:35: error: type mismatch: STRING + INTEGER"}},
+		{`let x: number = 1`, []string{"1:8: error: unknown type number"}},
+		{`len("a") + 1`, nil},
+		{`import "math"; math.PI + "x"`, nil},
+		{`let f = fn(x: int) { fn(y: int) { x + y } }; f(1)(2) + "a"`,
+			[]string{"1:54: error: type mismatch: INTEGER + STRING"}},
+	}
+
+	for _, tt := range tests {
+		got := testTypes(t, tt.input)
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("wrong diagnostics for %q\nwant=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
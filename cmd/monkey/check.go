@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"monkey/check"
+	"monkey/lexer"
+	"monkey/parser"
+	"os"
+)
+
+// runCheck prints the diagnostics for files and returns the exit status: 1 if
+// any file fails to parse or has errors, 0 otherwise.
+func runCheck(files []string, out io.Writer) int {
+	if len(files) == 0 {
+		fmt.Fprint(out, usage)
+		return 2
+	}
+
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			status = 1
+			continue
+		}
+
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if errors := p.Errors(); len(errors) != 0 {
+			for _, err := range errors {
+				fmt.Fprintf(out, "%s: parse error: %s\n", file, err)
+			}
+			status = 1
+			continue
+		}
+
+		diags := append(check.Check(program), check.Types(program)...)
+		check.Sort(diags)
+		for _, d := range diags {
+			fmt.Fprintf(out, "%s:%s\n", file, d)
+			if d.Severity == check.SEVERITY_ERROR {
+				status = 1
+			}
+		}
+	}
+	return status
+}
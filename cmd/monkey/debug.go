@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"monkey/debug"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+)
+
+// runDebug runs file under the debugger, reading commands from in, and
+// returns the exit status: 1 if the file fails to parse or its evaluation
+// fails, 0 otherwise.
+func runDebug(args []string, in io.Reader, out io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(out, usage)
+		return 2
+	}
+	file := args[0]
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		for _, err := range errors {
+			fmt.Fprintf(out, "%s: parse error: %s\n", file, err)
+		}
+		return 1
+	}
+
+	// runaway recursion fails with a stack depth error the debugger
+	// reports, instead of crashing it
+	e := eval.New(context.Background(), eval.Limits{MaxDepth: eval.DefaultMaxDepth})
+	e.Builtins = map[string]*object.Builtin{"puts": eval.Puts(out)}
+	loader := eval.FSLoader{FS: os.DirFS(filepath.Dir(file))}
+	e.Modules = eval.NewModules(loader)
+
+	d := debug.New(string(src), in, out)
+	d.Loader = loader
+	result := d.Run(e, program, object.NewEnvironment())
+	if err, ok := result.(*object.Error); ok {
+		fmt.Fprintf(out, "%s: %s\n", file, err.Inspect())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"monkey/lexer"
+	"monkey/lint"
+	"monkey/parser"
+	"os"
+)
+
+// defaultLintConfig is read by monkey lint if it exists and no -config is
+// given.
+const defaultLintConfig = ".monkeylint.json"
+
+// runLint prints the lint issues in files and returns the exit status: 1 if
+// any file fails to parse or has issues, 0 otherwise.
+func runLint(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(out)
+	configPath := flags.String("config", "", "read the lint `config` from this file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprint(out, usage)
+		return 2
+	}
+
+	config := lint.DefaultConfig()
+	path := *configPath
+	if path == "" {
+		if _, err := os.Stat(defaultLintConfig); !errors.Is(err, fs.ErrNotExist) {
+			path = defaultLintConfig
+		}
+	}
+	if path != "" {
+		var err error
+		if config, err = lint.LoadConfig(path); err != nil {
+			fmt.Fprintln(out, err)
+			return 2
+		}
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			status = 1
+			continue
+		}
+
+		l := lexer.New(string(src))
+		p := parser.New(l)
+		program := p.ParseProgram()
+		if errors := p.Errors(); len(errors) != 0 {
+			for _, err := range errors {
+				fmt.Fprintf(out, "%s: parse error: %s\n", file, err)
+			}
+			status = 1
+			continue
+		}
+
+		for _, issue := range lint.Lint(program, l.Comments(), config) {
+			fmt.Fprintf(out, "%s:%s\n", file, issue)
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"monkey/dap"
+	"monkey/repl"
+	"os"
+)
+
+const usage = `usage:
+	monkey               start the REPL
+	monkey check FILE... report mistakes in the use of names and types
+	monkey lint [-config FILE] FILE...
+	                     report code that breaks the lint rules
+	monkey debug FILE    run FILE step by step under a debugger
+	monkey dap           serve the Debug Adapter Protocol on stdin and stdout
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		repl := repl.New(os.Stdin, os.Stdout)
+		if err := repl.Loop(); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	switch os.Args[1] {
+	case "check":
+		os.Exit(runCheck(os.Args[2:], os.Stdout))
+	case "lint":
+		os.Exit(runLint(os.Args[2:], os.Stdout))
+	case "debug":
+		os.Exit(runDebug(os.Args[2:], os.Stdin, os.Stdout))
+	case "dap":
+		if err := dap.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
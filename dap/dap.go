@@ -0,0 +1,396 @@
+// Package dap serves the Debug Adapter Protocol, which editors use to
+// drive debuggers. Serve debugs a single program on a single thread: it is
+// launched by path, runs once the client is done configuring breakpoints,
+// and can be stepped through with the stepping of package debug while
+// its stack, variables and expressions are inspected.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"monkey/ast"
+	"monkey/debug"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// threadID is the only thread, the evaluation of the program.
+const threadID = 1
+
+var resumeModes = map[string]debug.Mode{
+	"continue": debug.MODE_CONTINUE,
+	"next":     debug.MODE_NEXT,
+	"stepIn":   debug.MODE_STEP,
+	"stepOut":  debug.MODE_OUT,
+}
+
+// Serve reads requests from r and writes responses and events to w until
+// the client disconnects or r ends.
+func Serve(r io.Reader, w io.Writer) error {
+	s := &server{
+		in:      bufio.NewReader(r),
+		out:     w,
+		stepper: debug.NewStepper(),
+		resume:  make(chan debug.Mode),
+		done:    make(chan struct{}),
+	}
+	defer s.stop()
+
+	for {
+		content, err := readMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if !s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+type server struct {
+	in *bufio.Reader
+
+	// writing guards out and seq, events are sent by the evaluation too
+	writing sync.Mutex
+	out     io.Writer
+	seq     int
+
+	path    string
+	program *ast.Program
+	// statements holds the lines with statements of each source file
+	// looked at, by path
+	statements  map[string]map[int]bool
+	stopOnEntry bool
+	configured  bool
+	started     bool
+	eval        *eval.Evaluator
+
+	// mu guards what the evaluation shares with the requests
+	mu      sync.Mutex
+	stepper *debug.Stepper
+	// envs holds the environment each active call is at, the top level
+	// first
+	envs []*object.Environment
+	// stopped is set while the evaluation is stopped
+	stopped *pause
+	quit    bool
+
+	// resume lets a stopped evaluation go on, done is closed once it has
+	// ended
+	resume chan debug.Mode
+	done   chan struct{}
+}
+
+// pause is where the evaluation is stopped at. The variables references
+// handed out are only valid until it goes on.
+type pause struct {
+	pos   token.Position
+	calls []eval.Call
+	envs  []*object.Environment
+	refs  []any
+}
+
+// handle answers req and reports whether to go on serving.
+func (s *server) handle(req *request) bool {
+	var body any
+	var err error
+
+	switch req.Command {
+	case "initialize":
+		body = capabilities{SupportsConfigurationDoneRequest: true}
+		s.respond(req, body, nil)
+		s.send("initialized", nil)
+		return true
+	case "launch":
+		err = s.launch(req)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req)
+	case "configurationDone":
+		s.configured = true
+	case "threads":
+		body = map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}}
+	case "continue", "next", "stepIn", "stepOut":
+		// the response has to come before the stopped event of the step
+		_, err = s.paused()
+		if req.Command == "continue" {
+			body = map[string]any{"allThreadsContinued": true}
+		}
+		s.respond(req, body, err)
+		if err == nil {
+			// requests from now on must not see the pause the evaluation
+			// is leaving
+			s.mu.Lock()
+			s.stopped = nil
+			s.mu.Unlock()
+			s.resume <- resumeModes[req.Command]
+		}
+		return true
+	case "stackTrace":
+		body, err = s.stackTrace()
+	case "scopes":
+		body, err = s.scopes(req)
+	case "variables":
+		body, err = s.variables(req)
+	case "evaluate":
+		body, err = s.evaluate(req)
+	case "disconnect", "terminate":
+		s.stop()
+		s.respond(req, nil, nil)
+		return req.Command != "disconnect"
+	default:
+		err = fmt.Errorf("unsupported command: %s", req.Command)
+	}
+
+	s.respond(req, body, err)
+	if err == nil && s.program != nil && s.configured && !s.started {
+		s.start()
+	}
+	return true
+}
+
+func (s *server) respond(req *request, body any, err error) {
+	resp := response{Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+		resp.Body = nil
+	}
+	s.write(&resp, &resp.Seq)
+}
+
+func (s *server) send(name string, body any) {
+	ev := event{Type: "event", Event: name, Body: body}
+	s.write(&ev, &ev.Seq)
+}
+
+// write numbers msg by setting seq and writes it. Write errors are left to
+// the reads, which fail too once the client is gone.
+func (s *server) write(msg any, seq *int) {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+
+	s.seq++
+	*seq = s.seq
+	writeMessage(s.out, msg)
+}
+
+func (s *server) launch(req *request) error {
+	var args launchArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return err
+	}
+	if s.program != nil {
+		return fmt.Errorf("already launched %s", s.path)
+	}
+
+	src, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return fmt.Errorf("%s: %s", args.Program, strings.Join(errors, "; "))
+	}
+
+	s.path, s.program = filepath.Clean(args.Program), program
+	s.stopOnEntry = args.StopOnEntry
+	s.statements = map[string]map[int]bool{s.path: statementLines(program)}
+	return nil
+}
+
+// statementLines returns the lines of program that statements start on.
+func statementLines(program *ast.Program) map[int]bool {
+	lines := make(map[int]bool)
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			lines[node.Token.Pos.Line] = true
+		case *ast.ImportStatement:
+			lines[node.Token.Pos.Line] = true
+		case *ast.ReturnStatement:
+			lines[node.Token.Pos.Line] = true
+		case *ast.ExpressionStatement:
+			lines[node.Token.Pos.Line] = true
+		}
+		return true
+	})
+	return lines
+}
+
+// sourcePath returns the path of the file of source, as named by
+// eval.Evaluator.Source. Modules are loaded from the directory of the
+// program.
+func (s *server) sourcePath(source string) string {
+	if source == "" {
+		return s.path
+	}
+	if path.Ext(source) == "" {
+		source += eval.ModuleExt
+	}
+	return filepath.Join(filepath.Dir(s.path), filepath.FromSlash(source))
+}
+
+// setBreakpoints replaces the breakpoints of a source file. Those on lines
+// without a statement are unverified, they are never hit. Until the
+// program is launched every breakpoint is taken as verified.
+func (s *server) setBreakpoints(req *request) (any, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	file := filepath.Clean(args.Source.Path)
+
+	lines, ok := s.statements[file]
+	if !ok && s.statements != nil {
+		// files that cannot be parsed have no statements to stop at
+		if src, err := os.ReadFile(file); err == nil {
+			p := parser.New(lexer.New(string(src)))
+			if program := p.ParseProgram(); len(p.Errors()) == 0 {
+				lines = statementLines(program)
+			}
+		}
+		s.statements[file] = lines
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	maps.DeleteFunc(s.stepper.Breakpoints, func(loc debug.Location, _ bool) bool {
+		return loc.Source == file
+	})
+	breakpoints := make([]breakpoint, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		verified := s.statements == nil || lines[bp.Line]
+		if verified {
+			s.stepper.Breakpoints[debug.Location{Source: file, Line: bp.Line}] = true
+		}
+		breakpoints[i] = breakpoint{Verified: verified, Line: bp.Line}
+	}
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+// start evaluates the program in the background.
+func (s *server) start() {
+	s.started = true
+	if !s.stopOnEntry {
+		s.stepper.Resume(debug.MODE_CONTINUE, 0)
+	}
+
+	// runaway recursion fails with a stack depth error sent to the client,
+	// instead of crashing the adapter
+	s.eval = eval.New(context.Background(), eval.Limits{MaxDepth: eval.DefaultMaxDepth})
+	s.eval.Builtins = map[string]*object.Builtin{"puts": eval.Puts(output{s})}
+	s.eval.Modules = eval.NewModules(eval.FSLoader{FS: os.DirFS(filepath.Dir(s.path))})
+	s.eval.Hook = s.hook
+
+	go func() {
+		defer close(s.done)
+
+		exitCode := 0
+		if err, ok := s.eval.Eval(s.program, object.NewEnvironment()).(*object.Error); ok {
+			exitCode = 1
+			if err.Kind != object.ERR_CANCELLED {
+				s.send("output", map[string]any{"category": "stderr", "output": err.Inspect() + "\n"})
+			}
+		}
+		s.send("exited", map[string]any{"exitCode": exitCode})
+		s.send("terminated", nil)
+	}()
+}
+
+// stop aborts the evaluation, if it is running, and waits for its end.
+func (s *server) stop() {
+	if !s.started {
+		return
+	}
+
+	s.mu.Lock()
+	quit := s.quit
+	s.quit = true
+	s.mu.Unlock()
+
+	if !quit {
+		close(s.resume)
+	}
+	<-s.done
+}
+
+// output sends what the program prints as output events.
+type output struct {
+	s *server
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.s.send("output", map[string]any{"category": "stdout", "output": string(p)})
+	return len(p), nil
+}
+
+// hook runs in the evaluation, stopping it where the stepper says until a
+// request lets it go on.
+func (s *server) hook(node ast.Node, env *object.Environment) *object.Error {
+	depth := s.eval.Depth()
+
+	s.mu.Lock()
+	for len(s.envs) <= depth {
+		s.envs = append(s.envs, nil)
+	}
+	s.envs = s.envs[:depth+1]
+	s.envs[depth] = env
+
+	if s.quit {
+		s.mu.Unlock()
+		return object.NewError(object.ERR_CANCELLED)
+	}
+	source := s.sourcePath(s.eval.Source())
+	pos, ok := s.stepper.Stops(node, source, depth)
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+
+	reason := "step"
+	if s.stopOnEntry {
+		reason, s.stopOnEntry = "entry", false
+	} else if s.stepper.Breakpoints[debug.Location{Source: source, Line: pos.Line}] {
+		reason = "breakpoint"
+	}
+	s.stopped = &pause{pos: pos, calls: s.eval.Calls(), envs: slices.Clone(s.envs)}
+	s.mu.Unlock()
+
+	s.send("stopped", map[string]any{"reason": reason, "threadId": threadID, "allThreadsStopped": true})
+	mode, ok := <-s.resume
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = nil
+	if !ok {
+		return object.NewError(object.ERR_CANCELLED)
+	}
+	s.stepper.Resume(mode, depth)
+	return nil
+}
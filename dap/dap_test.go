@@ -0,0 +1,504 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const program = `let add = fn(a, b) {
+	let sum = a + b;
+	sum
+};
+let twice = fn(x) {
+	let y = add(x, x);
+	y * 2
+};
+let xs = [1, {"k": 2}];
+let r = twice(3);
+puts(r);`
+
+// message is any message the server sends.
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client drives a server the way an editor would.
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	seq    int
+	events []message
+	msgs   chan message
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	served := make(chan error)
+	go func() {
+		served <- Serve(serverR, serverW)
+		serverW.Close()
+	}()
+	t.Cleanup(func() {
+		clientW.Close()
+		if err := <-served; err != nil {
+			t.Errorf("Serve failed: %s", err)
+		}
+	})
+
+	c := &client{t: t, w: clientW, r: bufio.NewReader(clientR), msgs: make(chan message)}
+	go func() {
+		defer close(c.msgs)
+		for {
+			content, err := readMessage(c.r)
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(content, &msg); err != nil {
+				t.Errorf("invalid message %s: %s", content, err)
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) next() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatalf("the server is gone")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("no message from the server")
+		return message{}
+	}
+}
+
+// request sends a request and returns its response, keeping the events
+// sent meanwhile for event.
+func (c *client) request(command string, args any) message {
+	c.t.Helper()
+
+	c.seq++
+	req := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	if err := writeMessage(c.w, req); err != nil {
+		c.t.Fatalf("cannot send %s: %s", command, err)
+	}
+
+	for {
+		msg := c.next()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq || msg.Command != command {
+			c.t.Fatalf("response to %s expected, got=%+v", command, msg)
+		}
+		return msg
+	}
+}
+
+// success is like request but fails the test unless the request succeeds,
+// decoding the body of the response into body.
+func (c *client) success(command string, args any, body any) {
+	c.t.Helper()
+	resp := c.request(command, args)
+	if !resp.Success {
+		c.t.Fatalf("%s failed: %s", command, resp.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(resp.Body, body); err != nil {
+			c.t.Fatalf("invalid body of %s: %s", command, err)
+		}
+	}
+}
+
+// event waits for the event named name, decoding its body into body.
+func (c *client) event(name string, body any) {
+	c.t.Helper()
+
+	for {
+		var msg message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type != "event" || msg.Event != name {
+			continue
+		}
+		if body != nil {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatalf("invalid body of %s: %s", name, err)
+			}
+		}
+		return
+	}
+}
+
+func (c *client) stopped(reason string, line int) {
+	c.t.Helper()
+
+	var ev struct {
+		Reason   string `json:"reason"`
+		ThreadID int    `json:"threadId"`
+	}
+	c.event("stopped", &ev)
+	if ev.Reason != reason || ev.ThreadID != threadID {
+		c.t.Errorf("stopped with reason %q on thread %d, expected %q", ev.Reason, ev.ThreadID, reason)
+	}
+
+	frames := c.stackTrace()
+	if frames[0].Line != line {
+		c.t.Errorf("stopped at line %d, expected %d", frames[0].Line, line)
+	}
+}
+
+func (c *client) stackTrace() []stackFrame {
+	c.t.Helper()
+	var body struct {
+		StackFrames []stackFrame `json:"stackFrames"`
+	}
+	c.success("stackTrace", map[string]any{"threadId": threadID}, &body)
+	return body.StackFrames
+}
+
+func (c *client) variables(ref int) map[string]variable {
+	c.t.Helper()
+	var body struct {
+		Variables []variable `json:"variables"`
+	}
+	c.success("variables", map[string]any{"variablesReference": ref}, &body)
+
+	vars := make(map[string]variable)
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func (c *client) evaluate(expr string, frame int) string {
+	c.t.Helper()
+	var body struct {
+		Result string `json:"result"`
+	}
+	c.success("evaluate", map[string]any{"expression": expr, "frameId": frame}, &body)
+	return body.Result
+}
+
+// writeFile writes src to the file name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, src string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setBreakpoints sets the breakpoints of the file at path, checking that
+// only those in unverified are unverified.
+func (c *client) setBreakpoints(path string, lines []int, unverified ...int) {
+	c.t.Helper()
+
+	args := make([]map[string]int, len(lines))
+	for i, line := range lines {
+		args[i] = map[string]int{"line": line}
+	}
+	var body struct {
+		Breakpoints []breakpoint `json:"breakpoints"`
+	}
+	c.success("setBreakpoints", map[string]any{"source": map[string]string{"path": path}, "breakpoints": args}, &body)
+	if len(body.Breakpoints) != len(lines) {
+		c.t.Fatalf("wrong breakpoints %+v for lines %v", body.Breakpoints, lines)
+	}
+	for i, bp := range body.Breakpoints {
+		if bp.Line != lines[i] || bp.Verified == slices.Contains(unverified, bp.Line) {
+			c.t.Errorf("wrong breakpoint %+v for line %d", bp, lines[i])
+		}
+	}
+}
+
+// start launches the program at path and configures it with configure.
+func start(t *testing.T, c *client, path string, stopOnEntry bool, configure func()) {
+	t.Helper()
+
+	var caps capabilities
+	c.success("initialize", map[string]any{"adapterID": "monkey"}, &caps)
+	if !caps.SupportsConfigurationDoneRequest {
+		t.Errorf("configurationDone should be supported")
+	}
+	c.event("initialized", nil)
+	c.success("launch", map[string]any{"program": path, "stopOnEntry": stopOnEntry}, nil)
+	configure()
+	c.success("configurationDone", nil, nil)
+}
+
+func launch(t *testing.T, c *client, stopOnEntry bool, breakpoints ...int) {
+	t.Helper()
+
+	path := writeFile(t, t.TempDir(), "main.mk", program)
+	start(t, c, path, stopOnEntry, func() {
+		// line 4 only closes a function
+		c.setBreakpoints(path, breakpoints, 4)
+	})
+}
+
+func TestSession(t *testing.T) {
+	c := newClient(t)
+	launch(t, c, false, 2, 4)
+
+	var threads struct {
+		Threads []thread `json:"threads"`
+	}
+	c.success("threads", nil, &threads)
+	if len(threads.Threads) != 1 || threads.Threads[0].ID != threadID {
+		t.Errorf("wrong threads, got=%+v", threads.Threads)
+	}
+
+	c.stopped("breakpoint", 2)
+	frames := c.stackTrace()
+	expected := []stackFrame{
+		{ID: 1, Name: "add", Line: 2, Column: 2},
+		{ID: 2, Name: "twice", Line: 6, Column: 13},
+		{ID: 3, Name: "<main>", Line: 10, Column: 14},
+	}
+	if len(frames) != len(expected) {
+		t.Fatalf("wrong stack trace, got=%+v", frames)
+	}
+	for i, frame := range frames {
+		if frame.ID != expected[i].ID || frame.Name != expected[i].Name ||
+			frame.Line != expected[i].Line || frame.Column != expected[i].Column {
+			t.Errorf("wrong frame %d, expected=%+v, got=%+v", i, expected[i], frame)
+		}
+		if frame.Source == nil || filepath.Base(frame.Source.Path) != "main.mk" {
+			t.Errorf("wrong source of frame %d, got=%+v", i, frame.Source)
+		}
+	}
+
+	var scopes struct {
+		Scopes []scope `json:"scopes"`
+	}
+	c.success("scopes", map[string]any{"frameId": 1}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("wrong scopes, got=%+v", scopes.Scopes)
+	}
+	locals := c.variables(scopes.Scopes[0].VariablesReference)
+	if len(locals) != 2 || locals["a"].Value != "3" || locals["b"].Type != "INTEGER" {
+		t.Errorf("wrong locals, got=%+v", locals)
+	}
+	globals := c.variables(scopes.Scopes[1].VariablesReference)
+	if _, ok := globals["twice"]; !ok || globals["xs"].VariablesReference == 0 {
+		t.Errorf("wrong globals, got=%+v", globals)
+	}
+	xs := c.variables(globals["xs"].VariablesReference)
+	if xs["[0]"].Value != "1" || xs["[1]"].VariablesReference == 0 {
+		t.Errorf("wrong elements, got=%+v", xs)
+	}
+	if hash := c.variables(xs["[1]"].VariablesReference); hash["k"].Value != "2" {
+		t.Errorf("wrong hash pairs, got=%+v", hash)
+	}
+
+	if got := c.evaluate("a + b", 1); got != "6" {
+		t.Errorf("a + b in add should be 6, got=%s", got)
+	}
+	if got := c.evaluate("x * 10", 2); got != "30" {
+		t.Errorf("x * 10 in twice should be 30, got=%s", got)
+	}
+	if resp := c.request("evaluate", map[string]any{"expression": "x", "frameId": 1}); resp.Success || resp.Message != "identifier not found: x" {
+		t.Errorf("evaluating an unknown name should fail, got=%+v", resp)
+	}
+	// expressions that never end fail instead of hanging the adapter
+	for expr, msg := range map[string]string{
+		"let f = fn() { f() + 1 }; f()":    "stack depth exceeded",
+		"let g = fn(n) { g(n + 1) }; g(0)": "step limit exceeded",
+	} {
+		if resp := c.request("evaluate", map[string]any{"expression": expr, "frameId": 1}); resp.Success || !strings.Contains(resp.Message, msg) {
+			t.Errorf("evaluating %q should fail with %q, got=%+v", expr, msg, resp)
+		}
+	}
+
+	c.success("next", map[string]any{"threadId": threadID}, nil)
+	c.stopped("step", 3)
+	if got := c.evaluate("sum", 0); got != "6" {
+		t.Errorf("sum should be 6, got=%s", got)
+	}
+
+	c.success("stepOut", map[string]any{"threadId": threadID}, nil)
+	c.stopped("step", 7)
+
+	c.success("next", map[string]any{"threadId": threadID}, nil)
+	c.stopped("step", 11)
+
+	c.success("continue", map[string]any{"threadId": threadID}, nil)
+	if resp := c.request("stackTrace", map[string]any{"threadId": threadID}); resp.Success {
+		t.Errorf("a program that was continued should not be stopped any more")
+	}
+	var output struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Category != "stdout" || output.Output != "12\n" {
+		t.Errorf("wrong output, got=%+v", output)
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("wrong exit code %d", exited.ExitCode)
+	}
+	c.event("terminated", nil)
+
+	if resp := c.request("continue", map[string]any{"threadId": threadID}); resp.Success {
+		t.Errorf("continuing a finished program should fail")
+	}
+	c.success("disconnect", nil, nil)
+}
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	main := writeFile(t, dir, "main.mk", `import "lib";
+let r = lib.twice(3);
+puts(r);`)
+	lib := writeFile(t, dir, "lib.mk", `let twice = fn(x) {
+	let y = x * 2;
+	y
+};`)
+
+	c := newClient(t)
+	start(t, c, main, false, func() {
+		c.setBreakpoints(main, []int{2})
+		// breakpoints of other files are kept
+		c.setBreakpoints(lib, []int{2, 4}, 4)
+	})
+
+	c.stopped("breakpoint", 2)
+	if frames := c.stackTrace(); frames[0].Source == nil || frames[0].Source.Path != main {
+		t.Errorf("wrong source of the program, got=%+v", frames[0].Source)
+	}
+
+	c.success("continue", map[string]any{"threadId": threadID}, nil)
+	c.stopped("breakpoint", 2)
+	frames := c.stackTrace()
+	expected := []struct {
+		name, path string
+		line       int
+	}{
+		{"twice", lib, 2},
+		{"<main>", main, 2},
+	}
+	if len(frames) != len(expected) {
+		t.Fatalf("wrong stack trace, got=%+v", frames)
+	}
+	for i, frame := range frames {
+		if frame.Name != expected[i].name || frame.Line != expected[i].line ||
+			frame.Source == nil || frame.Source.Path != expected[i].path || frame.Source.Name != filepath.Base(expected[i].path) {
+			t.Errorf("wrong frame %d, expected=%+v, got=%+v (%+v)", i, expected[i], frame, frame.Source)
+		}
+	}
+
+	// clearing the breakpoints of a file leaves those of the others
+	c.setBreakpoints(lib, nil)
+	c.success("continue", map[string]any{"threadId": threadID}, nil)
+	var output struct {
+		Output string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Output != "6\n" {
+		t.Errorf("wrong output, got=%+v", output)
+	}
+	c.event("terminated", nil)
+}
+
+func TestStepIn(t *testing.T) {
+	c := newClient(t)
+	launch(t, c, true)
+
+	c.stopped("entry", 1)
+	for _, line := range []int{5, 9, 10, 6, 2} {
+		c.success("stepIn", map[string]any{"threadId": threadID}, nil)
+		c.stopped("step", line)
+	}
+
+	// disconnecting aborts the program
+	c.success("disconnect", nil, nil)
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("wrong exit code %d", exited.ExitCode)
+	}
+}
+
+func TestStackDepth(t *testing.T) {
+	c := newClient(t)
+	path := writeFile(t, t.TempDir(), "main.mk",
+		"let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } };\nf(1000000)")
+	start(t, c, path, false, func() {})
+
+	var output struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Category != "stderr" || !strings.HasPrefix(output.Output, "ERROR: stack depth exceeded") {
+		t.Errorf("wrong output, got=%.100q", output.Output)
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("wrong exit code %d", exited.ExitCode)
+	}
+	c.event("terminated", nil)
+	c.success("disconnect", nil, nil)
+}
+
+func TestRequestErrors(t *testing.T) {
+	c := newClient(t)
+
+	tests := []struct {
+		command string
+		args    any
+		msg     string
+	}{
+		{"stackTrace", nil, "the program is not stopped"},
+		{"launch", map[string]any{"program": filepath.Join(t.TempDir(), "missing.mk")}, ""},
+		{"restartFrame", nil, "unsupported command: restartFrame"},
+	}
+	for _, tt := range tests {
+		resp := c.request(tt.command, tt.args)
+		if resp.Success || tt.msg != "" && resp.Message != tt.msg {
+			t.Errorf("%s should fail with %q, got=%+v", tt.command, tt.msg, resp)
+		}
+	}
+}
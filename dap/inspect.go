@@ -0,0 +1,184 @@
+package dap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"monkey/debug"
+	"monkey/object"
+	"path/filepath"
+	"slices"
+)
+
+// The requests inspecting a stopped evaluation. They run while the
+// evaluation waits to be resumed, so they can use its state without
+// holding mu once they have the pause.
+
+var errNotStopped = errors.New("the program is not stopped")
+
+func (s *server) paused() (*pause, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped == nil {
+		return nil, errNotStopped
+	}
+	return s.stopped, nil
+}
+
+// Frames are numbered from 1 for the innermost call, the top level of the
+// program being the last.
+
+func (s *server) stackTrace() (any, error) {
+	p, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := debug.Backtrace(p.calls, p.pos)
+	stackFrames := make([]stackFrame, len(frames))
+	for i, frame := range frames {
+		path := s.sourcePath(frame.Source)
+		stackFrames[i] = stackFrame{
+			ID:     i + 1,
+			Name:   frame.Function,
+			Source: &source{Name: filepath.Base(path), Path: path},
+			Line:   frame.Pos.Line,
+			Column: frame.Pos.Column,
+		}
+	}
+	return map[string]any{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+// frameEnv returns the environment frame is at, the innermost frame's for
+// frame 0.
+func (p *pause) frameEnv(frame int) (*object.Environment, error) {
+	depth := len(p.calls) - max(frame-1, 0)
+	if depth < 0 || depth >= len(p.envs) || p.envs[depth] == nil {
+		return nil, fmt.Errorf("unknown frame %d", frame)
+	}
+	return p.envs[depth], nil
+}
+
+// scopeRef is what a variables reference to a scope refers to: the
+// variables of the environments from env up to, but not including, until.
+type scopeRef struct {
+	env, until *object.Environment
+}
+
+// reference hands out a variables reference to target, a scopeRef or an
+// object with elements.
+func (p *pause) reference(target any) int {
+	p.refs = append(p.refs, target)
+	return len(p.refs)
+}
+
+func (s *server) scopes(req *request) (any, error) {
+	var args struct {
+		FrameID int `json:"frameId"`
+	}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	p, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	env, err := p.frameEnv(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	global := env
+	for global.Outer() != nil {
+		global = global.Outer()
+	}
+
+	scopes := []scope{{Name: "Globals", VariablesReference: p.reference(scopeRef{env: global})}}
+	if env != global {
+		locals := scope{Name: "Locals", VariablesReference: p.reference(scopeRef{env: env, until: global})}
+		scopes = append([]scope{locals}, scopes...)
+	}
+	return map[string]any{"scopes": scopes}, nil
+}
+
+func (s *server) variables(req *request) (any, error) {
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	p, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	if args.VariablesReference < 1 || args.VariablesReference > len(p.refs) {
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+
+	var variables []variable
+	switch target := p.refs[args.VariablesReference-1].(type) {
+	case scopeRef:
+		// inner scopes shadow the variables of outer ones
+		var seen []string
+		for env := target.env; env != nil && env != target.until; env = env.Outer() {
+			for _, name := range env.Names() {
+				if slices.Contains(seen, name) {
+					continue
+				}
+				seen = append(seen, name)
+				val, _ := env.Get(name)
+				variables = append(variables, p.variable(name, val))
+			}
+		}
+	case *object.Array:
+		for i, elem := range target.Elements {
+			variables = append(variables, p.variable(fmt.Sprintf("[%d]", i), elem))
+		}
+	case *object.Hash:
+		for _, pair := range target.Pairs() {
+			variables = append(variables, p.variable(pair.Key.Inspect(), pair.Value))
+		}
+	}
+	return map[string]any{"variables": variables}, nil
+}
+
+// variable describes val, handing out a reference to its elements if it
+// has any.
+func (p *pause) variable(name string, val object.Object) variable {
+	v := variable{Name: name, Value: val.Inspect(), Type: string(val.Type())}
+	switch val := val.(type) {
+	case *object.Array:
+		if len(val.Elements) > 0 {
+			v.VariablesReference = p.reference(val)
+		}
+	case *object.Hash:
+		if val.Len() > 0 {
+			v.VariablesReference = p.reference(val)
+		}
+	}
+	return v
+}
+
+func (s *server) evaluate(req *request) (any, error) {
+	var args evaluateArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	p, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	env, err := p.frameEnv(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := debug.Evaluate(s.eval, args.Expression, env)
+	if err, ok := result.(*object.Error); ok {
+		return nil, errors.New(err.Msg)
+	}
+	v := p.variable("", result)
+	return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+}
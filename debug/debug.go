@@ -0,0 +1,351 @@
+// Package debug steps through the evaluation of a program interactively.
+// A Debugger stops before the first statement and whenever a breakpoint or
+// step command says so, and reads commands until told to go on:
+//
+//	break N, clear N     set or remove a breakpoint on line N
+//	continue             run until the next breakpoint
+//	step                 stop at the next line, entering calls
+//	next                 stop at the next line of the current call
+//	out                  stop in the caller once the current call returns
+//	print EXPR           evaluate EXPR in the current environment
+//	backtrace            list the active calls
+//	quit                 abort the program
+//
+// Each command can be abbreviated to its first letter, bt is backtrace.
+// Lines are those of the program unless prefixed with the path of a module
+// it imports, as in break lib/util:3.
+package debug
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Mode is how a stopped evaluation goes on.
+type Mode int
+
+const (
+	// MODE_CONTINUE runs until the next breakpoint
+	MODE_CONTINUE Mode = iota
+	// MODE_STEP stops at the next line, entering calls
+	MODE_STEP
+	// MODE_NEXT stops at the next line of the current call
+	MODE_NEXT
+	// MODE_OUT stops once the current call returns
+	MODE_OUT
+)
+
+// Location is a line of a source, named like eval.Evaluator.Source names
+// it or in any other way the host of the Stepper sees fit.
+type Location struct {
+	Source string
+	Line   int
+}
+
+func (l Location) String() string {
+	if l.Source == "" {
+		return strconv.Itoa(l.Line)
+	}
+	return fmt.Sprintf("%s:%d", l.Source, l.Line)
+}
+
+// Stepper decides where an evaluation stops, given the breakpoints and how
+// it was resumed. It only stops at the first statement evaluated on a line
+// of a call.
+type Stepper struct {
+	// Breakpoints holds the lines to stop at
+	Breakpoints map[Location]bool
+
+	mode Mode
+	// depth is the number of active calls when the evaluation was resumed
+	depth int
+	// line and lineDepth are where the last statement evaluated is
+	line      Location
+	lineDepth int
+}
+
+// NewStepper creates a Stepper stopping at the first statement.
+func NewStepper() *Stepper {
+	return &Stepper{Breakpoints: make(map[Location]bool), mode: MODE_STEP}
+}
+
+// Stops reports whether the evaluation stops at node of source, evaluated
+// with depth active calls, and where node is.
+func (s *Stepper) Stops(node ast.Node, source string, depth int) (token.Position, bool) {
+	pos, ok := statementPos(node)
+	line := Location{Source: source, Line: pos.Line}
+	if !ok || line == s.line && depth == s.lineDepth {
+		return pos, false
+	}
+	s.line, s.lineDepth = line, depth
+
+	if s.Breakpoints[line] {
+		return pos, true
+	}
+	switch s.mode {
+	case MODE_STEP:
+		return pos, true
+	case MODE_NEXT:
+		return pos, depth <= s.depth
+	case MODE_OUT:
+		return pos, depth < s.depth
+	default:
+		return pos, false
+	}
+}
+
+// Resume sets how the evaluation goes on from a stop with depth active
+// calls.
+func (s *Stepper) Resume(mode Mode, depth int) {
+	s.mode = mode
+	s.depth = depth
+}
+
+// Debugger drives an Evaluator, reading commands from in and writing to
+// out.
+type Debugger struct {
+	// Loader, if set, loads the sources of the modules the program
+	// imports, to show their lines
+	Loader eval.Loader
+
+	in    *bufio.Scanner
+	out   io.Writer
+	lines []string
+	// modules holds the lines of the modules loaded by Loader
+	modules map[string][]string
+
+	eval    *eval.Evaluator
+	stepper *Stepper
+	quit    bool
+}
+
+// New creates a debugger for a program made of src, to be run by Run.
+func New(src string, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		lines:   strings.Split(src, "\n"),
+		modules: make(map[string][]string),
+		stepper: NewStepper(),
+	}
+}
+
+// Run evaluates program in env with e under the control of the debugger
+// and returns its result. Quitting aborts the evaluation as cancelled.
+func (d *Debugger) Run(e *eval.Evaluator, program *ast.Program, env *object.Environment) object.Object {
+	d.eval = e
+	e.Hook = d.hook
+	defer func() { e.Hook = nil }()
+
+	return e.Eval(program, env)
+}
+
+func (d *Debugger) hook(node ast.Node, env *object.Environment) *object.Error {
+	source := d.eval.Source()
+	if pos, ok := d.stepper.Stops(node, source, d.eval.Depth()); ok && !d.quit {
+		d.stop(source, pos, env)
+	}
+	if d.quit {
+		return object.NewError(object.ERR_CANCELLED)
+	}
+	return nil
+}
+
+// statementPos returns the position of node if it is a statement, the
+// places a debugger stops at.
+func statementPos(node ast.Node) (token.Position, bool) {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return node.Token.Pos, true
+	case *ast.ImportStatement:
+		return node.Token.Pos, true
+	case *ast.ReturnStatement:
+		return node.Token.Pos, true
+	case *ast.ExpressionStatement:
+		return node.Token.Pos, true
+	default:
+		return token.Position{}, false
+	}
+}
+
+// stop shows where the program is stopped at and runs commands until one
+// resumes it.
+func (d *Debugger) stop(source string, pos token.Position, env *object.Environment) {
+	d.printLine(Location{Source: source, Line: pos.Line})
+
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+		if !d.in.Scan() {
+			// without more commands the program runs to its end
+			clear(d.stepper.Breakpoints)
+			d.resume(MODE_CONTINUE)
+			return
+		}
+
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(d.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "":
+		case "b", "break", "clear":
+			d.setBreakpoint(arg, cmd != "clear")
+		case "c", "continue":
+			d.resume(MODE_CONTINUE)
+			return
+		case "s", "step":
+			d.resume(MODE_STEP)
+			return
+		case "n", "next":
+			d.resume(MODE_NEXT)
+			return
+		case "o", "out":
+			d.resume(MODE_OUT)
+			return
+		case "p", "print":
+			d.print(arg, env)
+		case "bt", "backtrace":
+			d.backtrace(pos)
+		case "q", "quit":
+			d.quit = true
+			return
+		default:
+			fmt.Fprintf(d.out, "unknown command: %s\n", cmd)
+		}
+	}
+}
+
+func (d *Debugger) resume(mode Mode) {
+	d.stepper.Resume(mode, d.eval.Depth())
+}
+
+func (d *Debugger) printLine(loc Location) {
+	lines := d.lines
+	if loc.Source != "" {
+		lines = d.moduleLines(loc.Source)
+	}
+
+	text := ""
+	if loc.Line >= 1 && loc.Line <= len(lines) {
+		text = strings.TrimRight(lines[loc.Line-1], "\r")
+	}
+	if loc.Source == "" {
+		fmt.Fprintf(d.out, "%4d| %s\n", loc.Line, text)
+	} else {
+		fmt.Fprintf(d.out, "%s| %s\n", loc, text)
+	}
+}
+
+// moduleLines returns the lines of the module source, none if it cannot be
+// loaded.
+func (d *Debugger) moduleLines(source string) []string {
+	lines, ok := d.modules[source]
+	if !ok && d.Loader != nil {
+		if src, err := d.Loader.Load(source); err == nil {
+			lines = strings.Split(src, "\n")
+		}
+		d.modules[source] = lines
+	}
+	return lines
+}
+
+func (d *Debugger) setBreakpoint(arg string, set bool) {
+	var loc Location
+	n := arg
+	if i := strings.LastIndexByte(arg, ':'); i >= 0 {
+		loc.Source, n = path.Clean(arg[:i]), arg[i+1:]
+	}
+	line, err := strconv.Atoi(n)
+	if err != nil || line < 1 {
+		fmt.Fprintf(d.out, "not a line number: %q\n", arg)
+		return
+	}
+	loc.Line = line
+
+	if set {
+		d.stepper.Breakpoints[loc] = true
+		fmt.Fprintf(d.out, "breakpoint at line %s\n", loc)
+	} else {
+		delete(d.stepper.Breakpoints, loc)
+		fmt.Fprintf(d.out, "cleared breakpoint at line %s\n", loc)
+	}
+}
+
+func (d *Debugger) print(src string, env *object.Environment) {
+	switch result := Evaluate(d.eval, src, env).(type) {
+	case *object.Error:
+		// the trace would only show the expression itself
+		fmt.Fprintf(d.out, "ERROR: %s\n", result.Msg)
+	default:
+		fmt.Fprintln(d.out, result.Inspect())
+	}
+}
+
+// EvaluateLimits bounds what Evaluate may do, so that an expression that
+// never ends, such as a call of a function recursing forever, cannot hang
+// the debugger.
+var EvaluateLimits = eval.Limits{MaxSteps: 1_000_000, MaxDepth: 1000, MaxMemory: 64 << 20}
+
+// Evaluate evaluates src in env, which is where a program evaluated by e is
+// stopped at, with the builtins and modules of e but within
+// EvaluateLimits and without stopping inside src. Parse errors are
+// returned as an error. src can change the program's variables.
+func Evaluate(e *eval.Evaluator, src string, env *object.Environment) object.Object {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return object.FormatError("%s", strings.Join(errors, "; "))
+	}
+
+	limited := eval.New(context.Background(), EvaluateLimits)
+	limited.Builtins, limited.Imports, limited.Modules = e.Builtins, e.Imports, e.Modules
+	if result := limited.Eval(program, env); result != nil {
+		return result
+	}
+	return object.NULL
+}
+
+// backtrace lists the active calls, innermost first, the way error traces
+// do.
+func (d *Debugger) backtrace(pos token.Position) {
+	for i, frame := range Backtrace(d.eval.Calls(), pos) {
+		fmt.Fprintf(d.out, "#%d %s\n", i, frame)
+	}
+}
+
+// Frame is an active call in a backtrace.
+type Frame struct {
+	Function string
+	// Source names the source Pos is in, see eval.Evaluator.Source
+	Source string
+	Pos    token.Position
+}
+
+func (f Frame) String() string {
+	if f.Source == "" {
+		return fmt.Sprintf("at %s (%s)", f.Function, f.Pos)
+	}
+	return fmt.Sprintf("at %s (%s:%s)", f.Function, f.Source, f.Pos)
+}
+
+// Backtrace turns calls, as returned by eval.Evaluator.Calls, into frames
+// innermost first, pos being where the innermost call is at. The last frame
+// is the top level of the program.
+func Backtrace(calls []eval.Call, pos token.Position) []Frame {
+	frames := make([]Frame, 0, len(calls)+1)
+	for _, call := range slices.Backward(calls) {
+		frames = append(frames, Frame{Function: call.Function, Source: call.Source, Pos: pos})
+		pos = call.Site
+	}
+	return append(frames, Frame{Function: "<main>", Pos: pos})
+}
@@ -0,0 +1,170 @@
+package debug
+
+import (
+	"context"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const program = `let add = fn(a, b) {
+	let sum = a + b;
+	sum
+};
+let twice = fn(x) {
+	let y = add(x, x);
+	y * 2
+};
+let r = twice(3);
+r + 1`
+
+func runSession(t *testing.T, commands string) (object.Object, string) {
+	t.Helper()
+
+	p := parser.New(lexer.New(program))
+	parsed := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %v", p.Errors())
+	}
+
+	var out strings.Builder
+	d := New(program, strings.NewReader(commands), &out)
+	e := eval.New(context.Background(), eval.Limits{})
+	return d.Run(e, parsed, object.NewEnvironment()), out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		expected string
+	}{
+		{
+			"breakpoint and backtrace",
+			"b 2\nc\nbt\np a + b\nc\n",
+			`   1| let add = fn(a, b) {
+(debug) breakpoint at line 2
+(debug)    2| 	let sum = a + b;
+(debug) #0 at add (2:2)
+#1 at twice (6:13)
+#2 at <main> (9:14)
+(debug) 6
+(debug) `,
+		},
+		{
+			"step in, over and out",
+			"n\nn\ns\ns\np x\no\np y\nn\nn\nc\n",
+			`   1| let add = fn(a, b) {
+(debug)    5| let twice = fn(x) {
+(debug)    9| let r = twice(3);
+(debug)    6| 	let y = add(x, x);
+(debug)    2| 	let sum = a + b;
+(debug) ERROR: identifier not found: x
+(debug)    7| 	y * 2
+(debug) 6
+(debug)   10| r + 1
+(debug) `,
+		},
+		{
+			"clear and bad commands",
+			"b 3\nb x\nfoo\nclear 3\nc\n",
+			`   1| let add = fn(a, b) {
+(debug) breakpoint at line 3
+(debug) not a line number: "x"
+(debug) unknown command: foo
+(debug) cleared breakpoint at line 3
+(debug) `,
+		},
+	}
+
+	for _, tt := range tests {
+		result, out := runSession(t, tt.commands)
+		if out != tt.expected {
+			t.Errorf("%s: wrong output, expected=\n%s\ngot=\n%s", tt.name, tt.expected, out)
+		}
+		if i, ok := result.(*object.Integer); !ok || i.Value != 13 {
+			t.Errorf("%s: wrong result, got=%v", tt.name, result)
+		}
+	}
+
+	result, out := runSession(t, "n\nq\n")
+	if err, ok := result.(*object.Error); !ok || err.Kind != object.ERR_CANCELLED {
+		t.Errorf("quitting should cancel the program, got=%v", result)
+	}
+	if strings.Count(out, "(debug)") != 2 {
+		t.Errorf("the debugger should not stop after quitting, got=\n%s", out)
+	}
+
+	// without more commands the program runs to its end
+	if result, _ := runSession(t, ""); result.Inspect() != "13" {
+		t.Errorf("wrong result without commands, got=%v", result)
+	}
+}
+
+func TestDebuggerModules(t *testing.T) {
+	src := `import "lib";
+let r = lib.twice(3);
+r + 1`
+	loader := eval.FSLoader{FS: fstest.MapFS{"lib.mk": {Data: []byte(`let twice = fn(x) {
+	let y = x * 2;
+	y
+};`)}}}
+
+	tests := []struct {
+		name     string
+		commands string
+		expected string
+	}{
+		{
+			"breakpoints of the program",
+			"b 2\nc\nc\n",
+			`   1| import "lib";
+(debug) breakpoint at line 2
+(debug)    2| let r = lib.twice(3);
+(debug) `,
+		},
+		{
+			"breakpoints of a module",
+			"b lib:2\nc\nbt\nn\nc\n",
+			`   1| import "lib";
+(debug) breakpoint at line lib:2
+(debug) lib:2| 	let y = x * 2;
+(debug) #0 at twice (lib:2:2)
+#1 at <main> (2:18)
+(debug) lib:3| 	y
+(debug) `,
+		},
+		{
+			"stepping into a module",
+			"s\ns\nbt\nc\n",
+			`   1| import "lib";
+(debug) lib:1| let twice = fn(x) {
+(debug)    2| let r = lib.twice(3);
+(debug) #0 at <main> (2:1)
+(debug) `,
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(src))
+		program := p.ParseProgram()
+
+		var out strings.Builder
+		d := New(src, strings.NewReader(tt.commands), &out)
+		d.Loader = loader
+		e := eval.New(context.Background(), eval.Limits{})
+		e.Modules = eval.NewModules(loader)
+
+		result := d.Run(e, program, object.NewEnvironment())
+		if out.String() != tt.expected {
+			t.Errorf("%s: wrong output, expected=\n%s\ngot=\n%s", tt.name, tt.expected, out.String())
+		}
+		if i, ok := result.(*object.Integer); !ok || i.Value != 7 {
+			t.Errorf("%s: wrong result, got=%v", tt.name, result)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package monkey
+
+import (
+	"fmt"
+	"monkey/object"
+	"strings"
+)
+
+// ParseError is returned when the source does not parse.
+type ParseError struct {
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	return "parse error: " + strings.Join(e.Errors, "; ")
+}
+
+// RuntimeError is returned when evaluation ends with an error object.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Err.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Err.Pos, e.Err.Msg)
+	}
+	return e.Err.Msg
+}
+
+// Kind distinguishes script failures from exceeded limits.
+func (e *RuntimeError) Kind() object.ErrorKind {
+	return e.Err.Kind
+}
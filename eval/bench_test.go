@@ -0,0 +1,74 @@
+package eval
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+// The benchmarks only use Eval, so they also run against older versions of
+// the evaluator. To measure a change, copy this file into a worktree of
+// the commit before it and compare both with benchstat:
+//
+//	git worktree add /tmp/base <commit>~1
+//	cp eval/bench_test.go /tmp/base/eval/
+//	(cd /tmp/base && go test ./eval -run '^$' -bench . -count 10) > old.txt
+//	go test ./eval -run '^$' -bench . -count 10 > new.txt
+//	benchstat old.txt new.txt
+
+func benchmarkEval(b *testing.B, input string) {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if result := Eval(program, object.NewEnvironment()); object.IsError(result) {
+			b.Fatal(result.Inspect())
+		}
+	}
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+	benchmarkEval(b, `
+let loop = fn(i, acc) {
+	if (i == 0) { return acc; }
+	loop(i - 1, acc + (i * i - 3 * i + 7) / 2 - (i - 1) * 2)
+};
+loop(10000, 0)`)
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	benchmarkEval(b, `
+let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+fib(20)`)
+}
+
+func BenchmarkClosures(b *testing.B) {
+	benchmarkEval(b, `
+let makeAdder = fn(x) { fn(y) { x + y } };
+let compose = fn(f, g) { fn(v) { f(g(v)) } };
+let loop = fn(i, acc) {
+	if (i == 0) { return acc; }
+	let add = makeAdder(i);
+	let twice = compose(add, add);
+	loop(i - 1, twice(acc) - acc - i)
+};
+loop(5000, 0)`)
+}
+
+func BenchmarkNestedScopes(b *testing.B) {
+	benchmarkEval(b, `
+let outer = fn(a, b, c) {
+	let d = a + b;
+	let inner = fn(n, acc) {
+		if (n == 0) { return acc; }
+		if (n > 0) {
+			let e = c * d;
+			if (e > 0) { inner(n - 1, acc + a + b + c + d + e) } else { 0 }
+		}
+	};
+	inner(10000, 0)
+};
+outer(1, 2, 3)`)
+}
@@ -0,0 +1,66 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"monkey/object"
+	"os"
+	"slices"
+)
+
+var builtins = map[string]*object.Builtin{
+	"throw": {Name: "throw", Fn: throw},
+	"puts":  Puts(os.Stdout),
+
+	"map":       {Name: "map", HigherOrder: mapArray},
+	"filter":    {Name: "filter", HigherOrder: filter},
+	"reduce":    {Name: "reduce", HigherOrder: reduce},
+	"each":      {Name: "each", HigherOrder: each},
+	"any":       {Name: "any", HigherOrder: anyElement},
+	"all":       {Name: "all", HigherOrder: allElements},
+	"find":      {Name: "find", HigherOrder: find},
+	"sort":      {Name: "sort", HigherOrder: sortArray},
+	"reverse":   {Name: "reverse", Fn: reverse},
+	"zip":       {Name: "zip", Allocating: zip},
+	"enumerate": {Name: "enumerate", Allocating: enumerate},
+	"range":     {Name: "range", Allocating: rangeArray},
+	"keys":      {Name: "keys", Fn: keys},
+	"values":    {Name: "values", Fn: values},
+	"entries":   {Name: "entries", Allocating: entries},
+}
+
+// BuiltinNames lists the names of the default builtins in sorted order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func throw(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.FormatError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	msg := args[0].Inspect()
+	if str, ok := args[0].(*object.String); ok {
+		msg = str.Value
+	}
+	return &object.Error{Msg: msg, Value: args[0]}
+}
+
+// Puts creates the puts builtin, printing each argument on its own line
+// to out.
+func Puts(out io.Writer) *object.Builtin {
+	return &object.Builtin{
+		Name: "puts",
+		Fn: func(args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(out, arg.Inspect())
+			}
+			return object.NULL
+		},
+	}
+}
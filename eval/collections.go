@@ -0,0 +1,414 @@
+package eval
+
+import (
+	"cmp"
+	"monkey/object"
+	"slices"
+)
+
+// maxRange bounds the arrays range builds, so a huge range fails with an
+// error even when the evaluation has no memory limit.
+const maxRange = 1 << 24
+
+func wrongArgCount(want, got int) *object.Error {
+	return object.FormatError("wrong number of arguments: want=%d, got=%d", want, got)
+}
+
+// arrayArg checks that args[0] is an array and that there are want
+// arguments, returning the elements.
+func arrayArg(args []object.Object, want int) ([]object.Object, *object.Error) {
+	if len(args) != want {
+		return nil, wrongArgCount(want, len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, object.FormatError("argument 1: want ARRAY, got %s", args[0].Type())
+	}
+	return arr.Elements, nil
+}
+
+// optionalFnArgs is like arrayArg for builtins taking an array and an
+// optional function.
+func optionalFnArgs(args []object.Object) ([]object.Object, *object.Error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, object.FormatError("wrong number of arguments: want 1 to 2, got=%d", len(args))
+	}
+	return arrayArg(args, len(args))
+}
+
+func hashArg(args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) != 1 {
+		return nil, wrongArgCount(1, len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, object.FormatError("argument 1: want HASH, got %s", args[0].Type())
+	}
+	return hash, nil
+}
+
+func pair(a, b object.Object) *object.Array {
+	return &object.Array{Elements: []object.Object{a, b}}
+}
+
+// pairsSize is the size of an array of n pairs.
+func pairsSize(n int) int64 {
+	return object.ArraySize(n) + int64(n)*object.ArraySize(2)
+}
+
+func mapArray(apply object.Applier, args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 2)
+	if err != nil {
+		return err
+	}
+
+	mapped := make([]object.Object, len(elements))
+	for i, el := range elements {
+		mapped[i] = apply(args[1], el)
+		if object.IsError(mapped[i]) {
+			return mapped[i]
+		}
+	}
+	return &object.Array{Elements: mapped}
+}
+
+func filter(apply object.Applier, args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 2)
+	if err != nil {
+		return err
+	}
+
+	kept := []object.Object{}
+	for _, el := range elements {
+		ok := apply(args[1], el)
+		if object.IsError(ok) {
+			return ok
+		}
+		if object.IsTruthy(ok) {
+			kept = append(kept, el)
+		}
+	}
+	return &object.Array{Elements: kept}
+}
+
+// reduce folds the array with fn(acc, el), starting from the optional
+// third argument or else the first element.
+func reduce(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) == 2 {
+		elements, err := arrayArg(args, 2)
+		if err != nil {
+			return err
+		}
+		if len(elements) == 0 {
+			return object.FormatError("reduce of empty ARRAY with no initial value")
+		}
+		args = []object.Object{&object.Array{Elements: elements[1:]}, args[1], elements[0]}
+	}
+
+	elements, err := arrayArg(args, 3)
+	if err != nil {
+		return err
+	}
+
+	acc := args[2]
+	for _, el := range elements {
+		acc = apply(args[1], acc, el)
+		if object.IsError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+func each(apply object.Applier, args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range elements {
+		if result := apply(args[1], el); object.IsError(result) {
+			return result
+		}
+	}
+	return object.NULL
+}
+
+// test returns the truthiness of fn(el), or of el itself if there is no
+// fn.
+func test(apply object.Applier, args []object.Object, el object.Object) (bool, object.Object) {
+	if len(args) == 1 {
+		return object.IsTruthy(el), nil
+	}
+
+	result := apply(args[1], el)
+	if object.IsError(result) {
+		return false, result
+	}
+	return object.IsTruthy(result), nil
+}
+
+// anyElement reports whether some element satisfies the optional
+// predicate, or is truthy.
+func anyElement(apply object.Applier, args ...object.Object) object.Object {
+	return quantify(apply, args, true)
+}
+
+// allElements reports whether every element satisfies the optional
+// predicate, or is truthy.
+func allElements(apply object.Applier, args ...object.Object) object.Object {
+	return quantify(apply, args, false)
+}
+
+// quantify stops at the first element whose test equals stop.
+func quantify(apply object.Applier, args []object.Object, stop bool) object.Object {
+	elements, err := optionalFnArgs(args)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range elements {
+		ok, err := test(apply, args, el)
+		if err != nil {
+			return err
+		}
+		if ok == stop {
+			return object.AsBool(stop)
+		}
+	}
+	return object.AsBool(!stop)
+}
+
+// find returns the first element satisfying the predicate, or null.
+func find(apply object.Applier, args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range elements {
+		ok, err := test(apply, args, el)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return el
+		}
+	}
+	return object.NULL
+}
+
+// sortArray returns a sorted copy of the array. Without a comparator it
+// sorts numbers and strings in ascending order; a comparator fn(a, b)
+// returns a negative integer if a comes first, a positive one if b does
+// and zero if they are equal. The sort is stable.
+func sortArray(apply object.Applier, args ...object.Object) object.Object {
+	elements, err := optionalFnArgs(args)
+	if err != nil {
+		return err
+	}
+	sorted := slices.Clone(elements)
+
+	var sortErr object.Object
+	compare := func(a, b object.Object) int {
+		if sortErr != nil {
+			return 0
+		}
+
+		c, err := compareNatural(a, b)
+		if len(args) == 2 {
+			c, err = compareWith(apply, args[1], a, b)
+		}
+		if err != nil {
+			sortErr = err
+		}
+		return c
+	}
+
+	slices.SortStableFunc(sorted, compare)
+	if sortErr != nil {
+		return sortErr
+	}
+	return &object.Array{Elements: sorted}
+}
+
+func compareNatural(a, b object.Object) (int, object.Object) {
+	switch {
+	case isNumber(a) && isNumber(b):
+		if a.Type() == object.OBJ_INTEGER && b.Type() == object.OBJ_INTEGER {
+			return cmp.Compare(a.(*object.Integer).Value, b.(*object.Integer).Value), nil
+		}
+		return cmp.Compare(toFloat(a), toFloat(b)), nil
+	case a.Type() == object.OBJ_STRING && b.Type() == object.OBJ_STRING:
+		return cmp.Compare(a.(*object.String).Value, b.(*object.String).Value), nil
+	default:
+		return 0, object.FormatError("cannot compare %s and %s, pass a comparator to sort",
+			a.Type(), b.Type())
+	}
+}
+
+func compareWith(apply object.Applier, fn, a, b object.Object) (int, object.Object) {
+	result := apply(fn, a, b)
+	if object.IsError(result) {
+		return 0, result
+	}
+
+	c, ok := result.(*object.Integer)
+	if !ok {
+		return 0, object.FormatError("comparator must return INTEGER, got %s", result.Type())
+	}
+	return cmp.Compare(c.Value, 0), nil
+}
+
+func reverse(args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 1)
+	if err != nil {
+		return err
+	}
+
+	reversed := slices.Clone(elements)
+	slices.Reverse(reversed)
+	return &object.Array{Elements: reversed}
+}
+
+// zip pairs up the elements of its arrays, stopping at the shortest.
+func zip(alloc object.Allocator, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return object.FormatError("wrong number of arguments: want at least 1, got=0")
+	}
+
+	arrays := make([][]object.Object, len(args))
+	length := -1
+	for i, arg := range args {
+		arr, ok := arg.(*object.Array)
+		if !ok {
+			return object.FormatError("argument %d: want ARRAY, got %s", i+1, arg.Type())
+		}
+		arrays[i] = arr.Elements
+		if length < 0 || len(arr.Elements) < length {
+			length = len(arr.Elements)
+		}
+	}
+
+	if err := alloc(object.ArraySize(length) + int64(length)*object.ArraySize(len(arrays))); err != nil {
+		return err
+	}
+	zipped := make([]object.Object, length)
+	for i := range zipped {
+		tuple := make([]object.Object, len(arrays))
+		for j, arr := range arrays {
+			tuple[j] = arr[i]
+		}
+		zipped[i] = &object.Array{Elements: tuple}
+	}
+	return &object.Array{Elements: zipped}
+}
+
+// enumerate pairs every element with its index.
+func enumerate(alloc object.Allocator, args ...object.Object) object.Object {
+	elements, err := arrayArg(args, 1)
+	if err != nil {
+		return err
+	}
+	if err := alloc(pairsSize(len(elements))); err != nil {
+		return err
+	}
+
+	pairs := make([]object.Object, len(elements))
+	for i, el := range elements {
+		pairs[i] = pair(object.AsInt(int64(i)), el)
+	}
+	return &object.Array{Elements: pairs}
+}
+
+// rangeArray builds the integers from start, 0 by default, up to but not
+// including stop, counting by step, 1 by default.
+func rangeArray(alloc object.Allocator, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return object.FormatError("wrong number of arguments: want 1 to 3, got=%d", len(args))
+	}
+
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		n, ok := arg.(*object.Integer)
+		if !ok {
+			return object.FormatError("argument %d: want INTEGER, got %s", i+1, arg.Type())
+		}
+		bounds[i] = n.Value
+	}
+
+	start, stop, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, stop = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return object.FormatError("range step must not be zero")
+	}
+
+	var length uint64
+	if step > 0 && stop > start {
+		length = (uint64(stop-start) + uint64(step) - 1) / uint64(step)
+	} else if step < 0 && stop < start {
+		length = (uint64(start-stop) + uint64(-step) - 1) / uint64(-step)
+	}
+	if length > maxRange {
+		return object.FormatError("range of %d elements too large", length)
+	}
+	if err := alloc(object.ArraySize(int(length))); err != nil {
+		return err
+	}
+
+	elements := make([]object.Object, length)
+	for i := range elements {
+		elements[i] = object.AsInt(start + int64(i)*step)
+	}
+	return &object.Array{Elements: elements}
+}
+
+func keys(args ...object.Object) object.Object {
+	hash, err := hashArg(args)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]object.Object, 0, hash.Len())
+	for _, p := range hash.Pairs() {
+		keys = append(keys, p.Key)
+	}
+	return &object.Array{Elements: keys}
+}
+
+func values(args ...object.Object) object.Object {
+	hash, err := hashArg(args)
+	if err != nil {
+		return err
+	}
+
+	values := make([]object.Object, 0, hash.Len())
+	for _, p := range hash.Pairs() {
+		values = append(values, p.Value)
+	}
+	return &object.Array{Elements: values}
+}
+
+// entries returns the [key, value] pairs of a hash in insertion order.
+func entries(alloc object.Allocator, args ...object.Object) object.Object {
+	hash, err := hashArg(args)
+	if err != nil {
+		return err
+	}
+	if err := alloc(pairsSize(hash.Len())); err != nil {
+		return err
+	}
+
+	entries := make([]object.Object, 0, hash.Len())
+	for _, p := range hash.Pairs() {
+		entries = append(entries, pair(p.Key, p.Value))
+	}
+	return &object.Array{Elements: entries}
+}
@@ -0,0 +1,164 @@
+package eval
+
+import (
+	"context"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`reduce([1, 2, 3, 4], fn(acc, x) { acc + x })`, "10"},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)`, "16"},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, "0"},
+		{`let sum = 0; each([1, 2, 3], fn(x) { sum = sum + x }); sum`, "6"},
+		{`any([1, 2, 3], fn(x) { x > 2 })`, "true"},
+		{`any([1, 2, 3], fn(x) { x > 3 })`, "false"},
+		{`any([false, 1])`, "true"},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, "true"},
+		{`all([1, 2, 3], fn(x) { x > 1 })`, "false"},
+		{`all([])`, "true"},
+		{`find([1, 2, 3, 4], fn(x) { x > 2 })`, "3"},
+		{`find([1, 2], fn(x) { x > 2 })`, "null"},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort([2.5, 1, 2])`, "[1, 2, 2.5]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([3, 1, 2], fn(a, b) { b - a })`, "[3, 2, 1]"},
+		{`sort([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], fn(a, b) { a[0] - b[0] })`,
+			"[[1, b], [1, d], [2, a], [2, c]]"},
+		{`let a = [3, 1, 2]; sort(a); a`, "[3, 1, 2]"},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1], [2], [3])`, "[[1, 2, 3]]"},
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{`range(4)`, "[0, 1, 2, 3]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(10, 0, -3)`, "[10, 7, 4, 1]"},
+		{`range(0, 10, 4)`, "[0, 4, 8]"},
+		{`range(5, 2)`, "[]"},
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`entries({"b": 1, "a": 2})`, "[[b, 1], [a, 2]]"},
+		{`let double = fn(x) { x * 2 }; let m = map; m([1], double)`, "[2]"},
+		{`let map = fn(a, f) { "mine" }; map([1], fn(x) { x })`, "mine"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestCollectionBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2], fn(x) { x + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`map([1, 2], fn(x, y) { x })`, "wrong number of arguments: want=2, got=1"},
+		{`map([1], 1)`, "not a function: INTEGER"},
+		{`map(1, fn(x) { x })`, "argument 1: want ARRAY, got INTEGER"},
+		{`map([1])`, "wrong number of arguments: want=2, got=1"},
+		{`filter([1], fn(x) { throw("no") })`, "no"},
+		{`reduce([], fn(acc, x) { acc })`, "reduce of empty ARRAY with no initial value"},
+		{`let n = 0; each([1, 2, 3], fn(x) { n = n + 1; if (x == 2) { throw("stop") } }); n`, "stop"},
+		{`any([1], fn(x) { x.y })`, "member access not supported: INTEGER.y"},
+		{`all()`, "wrong number of arguments: want 1 to 2, got=0"},
+		{`sort([1, "a"])`, "cannot compare STRING and INTEGER, pass a comparator to sort"},
+		{`sort([1, 2], fn(a, b) { true })`, "comparator must return INTEGER, got BOOLEAN"},
+		{`sort([1, 2, 3], fn(a, b) { throw("cmp") })`, "cmp"},
+		{`zip([1], 2)`, "argument 2: want ARRAY, got INTEGER"},
+		{`range(0, 10, 0)`, "range step must not be zero"},
+		{`range(1, "a")`, "argument 2: want INTEGER, got STRING"},
+		{`range(9223372036854775807)`, "range of 9223372036854775807 elements too large"},
+		{`keys([1])`, "argument 1: want HASH, got ARRAY"},
+		{`try { map([1], fn(x) { throw(x) }) } catch (e) { e + 1 }`, "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if err, ok := evaluated.(*object.Error); ok {
+			if err.Msg != tt.expected {
+				t.Errorf("%s: wrong error, expected=%q, got=%q", tt.input, tt.expected, err.Msg)
+			}
+			continue
+		}
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestCollectionBuiltinTrace(t *testing.T) {
+	input := `let check = fn(x) {
+	x + true
+};
+map([1], check);`
+
+	err, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+
+	inspected := "ERROR: type mismatch: INTEGER + BOOLEAN\n" +
+		"\tat check (2:4)\n\tat <main> (4:4)"
+	if err.Inspect() != inspected {
+		t.Errorf("err.Inspect() wrong, expected=%q, got=%q", inspected, err.Inspect())
+	}
+
+	limited := testEvalLimited(`map(range(100), fn(x) { x })`, Limits{MaxSteps: 50})
+	testErrorKind(t, limited, object.ERR_STEP_LIMIT)
+}
+
+func TestCollectionBuiltinMemory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		// the argument, the result and the pairs in it
+		{`enumerate([1, 2])`, (16 + 2*8) + (16 + 2*8) + 2*(16+2*8)},
+		{`zip([1, 2], [3])`, (16 + 2*8) + (16 + 8) + (16 + 8) + (16 + 2*8)},
+		{`entries({"a": 1})`, (16 + 1) + (16 + 40) + (16 + 8) + (16 + 2*8)},
+		{`range(3)`, 16 + 3*8},
+		{`range(1000)`, 16 + 1000*8},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluator := New(context.Background(), Limits{})
+		evaluator.Eval(program, object.NewEnvironment())
+		if evaluator.PeakMemory() != tt.expected {
+			t.Errorf("%s: PeakMemory() should be %d, got=%d", tt.input, tt.expected, evaluator.PeakMemory())
+		}
+
+		limited := New(context.Background(), Limits{MaxMemory: tt.expected - 1})
+		testErrorKind(t, limited.Eval(program, object.NewEnvironment()), object.ERR_MEMORY_LIMIT)
+		if limited.PeakMemory() >= tt.expected {
+			t.Errorf("%s: PeakMemory() should stay under the limit, got=%d", tt.input, limited.PeakMemory())
+		}
+	}
+
+	// huge results are refused before they are built
+	for _, input := range []string{
+		`range(16000000)`,
+		`let a = range(50000); zip(a, a)`,
+		`enumerate(range(50000))`,
+	} {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		limited := New(context.Background(), Limits{MaxMemory: 1 << 20})
+		testErrorKind(t, limited.Eval(program, object.NewEnvironment()), object.ERR_MEMORY_LIMIT)
+		if limited.PeakMemory() > 1<<20 {
+			t.Errorf("%s: PeakMemory() should stay within the limit, got=%d", input, limited.PeakMemory())
+		}
+	}
+}
@@ -1,69 +1,305 @@
 package eval
 
 import (
+	"cmp"
+	"context"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
+	"slices"
+	"time"
 )
 
+// DefaultMaxDepth is the number of nested function calls allowed unless
+// Limits sets another. Deeper recursion would exhaust the Go stack and
+// crash the host program.
+const DefaultMaxDepth = 10000
+
+// Limits bounds the work a single evaluation may do. Zero fields mean no
+// limit, except for MaxDepth.
+type Limits struct {
+	// MaxSteps is the number of nodes that may be evaluated
+	MaxSteps int
+	// MaxDepth is the number of nested function calls, tail calls replace
+	// the call they are made from instead of nesting. Zero means
+	// DefaultMaxDepth; a negative MaxDepth lifts the limit, leaving deep
+	// recursion to crash with a Go stack overflow.
+	MaxDepth int
+	// MaxMemory is the number of bytes the objects created by the run may
+	// take up, as estimated by object.SizeOf
+	MaxMemory int64
+}
+
+// Evaluator carries the state of one evaluation run across nested calls.
+type Evaluator struct {
+	// Builtins are looked up after the environment and before the default
+	// builtins, so hosts can add functions or replace the defaults.
+	Builtins map[string]*object.Builtin
+	// Imports are modules provided by the host. They are looked up before
+	// the standard library, so hosts can add modules or replace the defaults.
+	Imports map[string]*object.Module
+	// Modules resolves imports of anything but the standard library modules,
+	// which fail if it is nil.
+	Modules *Modules
+
+	done   <-chan struct{}
+	limits Limits
+
+	// Hook, if set, is called before each statement and expression is
+	// evaluated, with the environment it is evaluated in. Returning an
+	// error aborts the evaluation with it. Debuggers use it together with
+	// Calls.
+	Hook func(node ast.Node, env *object.Environment) *object.Error
+
+	steps  int
+	calls  []Call
+	memory int64
+	// source names the source of the code being evaluated, see Source
+	source string
+}
+
+// Call is an active call of a function, or the import of a module being
+// evaluated.
+type Call struct {
+	Function string
+	// Source names the source the function is defined in
+	Source string
+	// Site is where the function was called from, in the source of the
+	// caller
+	Site token.Position
+}
+
+func New(ctx context.Context, limits Limits) *Evaluator {
+	if limits.MaxDepth == 0 {
+		limits.MaxDepth = DefaultMaxDepth
+	}
+	return &Evaluator{done: ctx.Done(), limits: limits}
+}
+
+// Eval evaluates node without any limits but the default call depth.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New(context.Background(), Limits{}).Eval(node, env)
+}
+
+// EvalContext evaluates node, aborting once ctx is done or limits are
+// exceeded.
+func EvalContext(
+	ctx context.Context,
+	node ast.Node,
+	env *object.Environment,
+	limits Limits,
+) object.Object {
+	return New(ctx, limits).Eval(node, env)
+}
+
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	if err := e.enter(node, env); err != nil {
+		return err
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.Eval(node.Expression, env)
 	case *ast.PrefixExpression:
-		return evalPrefixExp(node, env)
+		return locate(e.evalPrefixExp(node, env), node.Token)
 	case *ast.InfixExpression:
-		return evalInfixExp(node, env)
+		return locate(e.evalInfixExp(node, env), node.Token)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
 	case *ast.BlockStatement:
-		return evalBlockStmt(node, env)
+		return e.evalBlockStmt(node, env)
 	case *ast.ReturnStatement:
-		return evalReturn(node, env)
+		return e.evalReturn(node, env)
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return object.AsInt(node.Value)
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return object.AsBool(node.Value)
+	case *ast.StringLiteral:
+		return e.alloc(&object.String{Value: node.Value})
 	case *ast.LetStatement:
-		return evalLetStmt(node, env)
+		return locate(e.evalLetStmt(node, env), node.Token)
+	case *ast.ImportStatement:
+		return locate(e.evalImportStmt(node, env), node.Token)
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return locate(e.evalIdentifier(node, env), node.Token)
+	case *ast.AssignExpression:
+		return locate(e.evalAssignExp(node, env), node.Token)
+	case *ast.FunctionLiteral:
+		return e.alloc(&object.Function{
+			Parameters: node.Parameters,
+			Body:       node.Body,
+			Env:        env,
+			Name:       node.Name,
+			Source:     e.source,
+		})
+	case *ast.CallExpression:
+		return locate(e.evalCallExp(node, env), node.Token)
+	case *ast.TryExpression:
+		return e.evalTryExp(node, env)
+	case *ast.ArrayLiteral:
+		elements := e.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && object.IsError(elements[0]) {
+			return elements[0]
+		}
+		return e.alloc(&object.Array{Elements: elements})
+	case *ast.HashLiteral:
+		return locate(e.evalHashLiteral(node, env), node.Token)
+	case *ast.IndexExpression:
+		return locate(e.evalIndexExp(node, env), node.Token)
+	case *ast.DotExpression:
+		return locate(e.evalDotExp(node, env), node.Token)
 	default:
 		return nil
 	}
 }
 
-func evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object {
-	obj, ok := env.Get(ident.Value)
+// enter accounts for evaluating node in env and shows it to the hook.
+func (e *Evaluator) enter(node ast.Node, env *object.Environment) *object.Error {
+	if err := e.step(); err != nil {
+		return err
+	}
+	if e.Hook != nil {
+		return e.Hook(node, env)
+	}
+	return nil
+}
+
+// Calls returns the active function calls and module imports, outermost
+// first. Tail calls replace the call they are made from.
+func (e *Evaluator) Calls() []Call {
+	return slices.Clone(e.calls)
+}
 
-	if !ok {
-		return object.FormatError("identifier not found: %s", ident.Value)
+// Source names the source of the code being evaluated: the path of the
+// module it is in, or "" for the program Eval was called with. Positions
+// of nodes are relative to their source.
+func (e *Evaluator) Source() string {
+	return e.source
+}
+
+// Depth returns the number of active function calls.
+func (e *Evaluator) Depth() int {
+	return len(e.calls)
+}
+
+// step accounts for a single evaluation step and reports why evaluation
+// has to stop, if it does.
+func (e *Evaluator) step() *object.Error {
+	select {
+	case <-e.done:
+		return object.NewError(object.ERR_CANCELLED)
+	default:
 	}
 
+	e.steps += 1
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		return object.NewError(object.ERR_STEP_LIMIT)
+	}
+	return nil
+}
+
+// alloc accounts for a newly created object. Allocations that would exceed
+// the memory limit are refused with a catchable error, so the script may
+// recover by doing something smaller.
+func (e *Evaluator) alloc(obj object.Object) object.Object {
+	if err := e.charge(object.SizeOf(obj)); err != nil {
+		return err
+	}
 	return obj
 }
-func evalLetStmt(ls *ast.LetStatement, env *object.Environment) object.Object {
-	val := Eval(ls.Value, env)
+
+// charge accounts for size bytes about to be allocated like alloc. It is
+// the object.Allocator of allocating builtins.
+func (e *Evaluator) charge(size int64) *object.Error {
+	if e.limits.MaxMemory > 0 && e.memory+size > e.limits.MaxMemory {
+		return object.NewError(object.ERR_MEMORY_LIMIT)
+	}
+
+	e.memory += size
+	return nil
+}
+
+// PeakMemory reports the bytes accounted to the run so far. Accounting
+// never releases memory, so this is the high-water mark that MaxMemory is
+// checked against.
+func (e *Evaluator) PeakMemory() int64 {
+	return e.memory
+}
+
+// locate tags an error produced while evaluating the node starting at tok
+// with its position. Errors raised deeper in the tree keep their own.
+func locate(obj object.Object, tok token.Token) object.Object {
+	if err, ok := obj.(*object.Error); ok {
+		err.Locate(tok.Pos)
+	}
+	return obj
+}
+
+func (e *Evaluator) evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object {
+	if ident.Resolved {
+		if obj, ok := env.GetAt(ident.Depth, ident.Slot, ident.Value); ok {
+			return obj
+		}
+	} else if obj, ok := env.Get(ident.Value); ok {
+		return obj
+	}
+
+	if builtin, ok := e.Builtins[ident.Value]; ok {
+		return builtin
+	}
+
+	if builtin, ok := builtins[ident.Value]; ok {
+		return builtin
+	}
+
+	return object.FormatError("identifier not found: %s", ident.Value)
+}
+
+func (e *Evaluator) evalLetStmt(ls *ast.LetStatement, env *object.Environment) object.Object {
+	val := e.Eval(ls.Value, env)
 	if object.IsError(val) {
 		return val
 	}
-	return env.Set(ls.Name.Value, val)
+	return env.DeclareAt(ownSlot(ls.Name), ls.Name.Value, val, ls.IsConst())
 }
 
-func evalReturn(rv *ast.ReturnStatement, env *object.Environment) object.Object {
-	val := Eval(rv.ReturnValue, env)
+func (e *Evaluator) evalAssignExp(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	val := e.Eval(ae.Value, env)
+	if object.IsError(val) {
+		return val
+	}
+
+	switch target := ae.Target.(type) {
+	case *ast.DotExpression:
+		return e.assignMember(target, val, env)
+	default:
+		ident := target.(*ast.Identifier)
+		if ident.Resolved {
+			return env.AssignAt(ident.Depth, ident.Slot, ident.Value, val)
+		}
+		return env.Assign(ident.Value, val)
+	}
+}
+
+func (e *Evaluator) evalReturn(rv *ast.ReturnStatement, env *object.Environment) object.Object {
+	val := e.Eval(rv.ReturnValue, env)
 	if object.IsError(val) {
 		return val
 	}
 	return &object.ReturnValue{Value: val}
 }
 
-func evalProgram(p *ast.Program, env *object.Environment) object.Object {
+func (e *Evaluator) evalProgram(p *ast.Program, env *object.Environment) object.Object {
+	resolve(p)
+
 	var result object.Object
 
 	for _, stmt := range p.Statements {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -75,8 +311,8 @@ func evalProgram(p *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalPrefixExp(node *ast.PrefixExpression, env *object.Environment) object.Object {
-	val := Eval(node.Right, env)
+func (e *Evaluator) evalPrefixExp(node *ast.PrefixExpression, env *object.Environment) object.Object {
+	val := e.Eval(node.Right, env)
 	if object.IsError(val) {
 		return val
 	}
@@ -106,86 +342,301 @@ func evalBang(right object.Object) object.Object {
 }
 
 func evalMinus(right object.Object) object.Object {
-	if right.Type() != object.OBJ_INTEGER {
+	switch right := right.(type) {
+	case *object.Integer:
+		return object.AsInt(-right.Value)
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.Duration:
+		return &object.Duration{Value: -right.Value}
+	default:
 		return object.FormatError("unknown operator: -%s",
 			right.Type())
 	}
-	integer := right.(*object.Integer)
-	return object.AsInt(-integer.Value)
 }
 
-func evalInfixExp(node *ast.InfixExpression, env *object.Environment) object.Object {
-	left := Eval(node.Left, env)
-	right := Eval(node.Right, env)
+func (e *Evaluator) evalInfixExp(node *ast.InfixExpression, env *object.Environment) object.Object {
+	n, result := e.evalInfix(node, env)
+	if result == nil {
+		return object.AsInt(n)
+	}
+	return result
+}
+
+// evalInfix evaluates an infix expression, returning an integer result
+// unboxed as n with a nil object. Operands that are infix expressions
+// themselves are evaluated the same way, so that the intermediate results
+// of arithmetic are never allocated.
+func (e *Evaluator) evalInfix(node *ast.InfixExpression, env *object.Environment) (int64, object.Object) {
+	leftInt, left, leftUnboxed := e.evalOperand(node.Left, env)
+	rightInt, right, rightUnboxed := e.evalOperand(node.Right, env)
 
 	if object.IsError(left) {
-		return left
+		return 0, left
 	}
 
 	if object.IsError(right) {
-		return right
+		return 0, right
+	}
+
+	if leftUnboxed && rightUnboxed {
+		return evalIntegerInfixExp(leftInt, rightInt, node.Operator)
+	}
+	if leftUnboxed {
+		left = object.AsInt(leftInt)
+	}
+	if rightUnboxed {
+		right = object.AsInt(rightInt)
 	}
 
 	switch {
-	case left.Type() == object.OBJ_INTEGER && right.Type() == object.OBJ_INTEGER:
-		return evalIntegerInfixExp(left, right, node.Operator)
+	case isNumber(left) && isNumber(right):
+		return 0, evalFloatInfixExp(left, right, node.Operator)
+	case left.Type() == object.OBJ_STRING && right.Type() == object.OBJ_STRING:
+		if node.Operator == "+" {
+			// the concatenation is charged before it is built
+			size := object.StringSize(len(left.(*object.String).Value) + len(right.(*object.String).Value))
+			if err := e.charge(size); err != nil {
+				return 0, err
+			}
+			return 0, evalStringInfixExp(left, right, node.Operator)
+		}
+		return 0, e.alloc(evalStringInfixExp(left, right, node.Operator))
+	case isTemporal(left) || isTemporal(right):
+		return 0, e.alloc(evalTimeInfixExp(left, right, node.Operator))
 	case left.Type() != right.Type():
-		return object.FormatError("type mismatch: %s %s %s",
+		return 0, object.FormatError("type mismatch: %s %s %s",
 			left.Type(), node.Operator, right.Type())
 	case node.Operator == "==":
-		return object.AsBool(left == right)
+		return 0, object.AsBool(left == right)
 	case node.Operator == "!=":
-		return object.AsBool(left != right)
+		return 0, object.AsBool(left != right)
 	default:
-		return object.FormatError("unknown operator: %s %s %s",
+		return 0, object.FormatError("unknown operator: %s %s %s",
 			left.Type(), node.Operator, right.Type())
 	}
 }
 
-func evalIntegerInfixExp(left, right object.Object, operator string) object.Object {
-	leftInt := left.(*object.Integer).Value
-	rightInt := right.(*object.Integer).Value
+// evalOperand evaluates an operand of an infix expression like Eval, but
+// returns integers unboxed as n, reporting them with unboxed.
+func (e *Evaluator) evalOperand(exp ast.Expression, env *object.Environment) (n int64, obj object.Object, unboxed bool) {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		if err := e.enter(exp, env); err != nil {
+			return 0, err, false
+		}
+		return exp.Value, nil, true
+	case *ast.InfixExpression:
+		if err := e.enter(exp, env); err != nil {
+			return 0, err, false
+		}
+		n, result := e.evalInfix(exp, env)
+		if result == nil {
+			return n, nil, true
+		}
+		return 0, locate(result, exp.Token), false
+	}
+
+	switch result := e.Eval(exp, env).(type) {
+	case *object.Integer:
+		return result.Value, nil, true
+	case nil:
+		// blocks without statements have no value
+		return 0, object.NULL, false
+	default:
+		return 0, result, false
+	}
+}
 
+func evalIntegerInfixExp(left, right int64, operator string) (int64, object.Object) {
 	switch operator {
 	case "+":
-		return object.AsInt(leftInt + rightInt)
+		return left + right, nil
 	case "-":
-		return object.AsInt(leftInt - rightInt)
+		return left - right, nil
 	case "*":
-		return object.AsInt(leftInt * rightInt)
+		return left * right, nil
 	case "/":
-		return object.AsInt(leftInt / rightInt)
+		if right == 0 {
+			return 0, object.FormatError("division by zero")
+		}
+		return left / right, nil
 	case ">":
-		return object.AsBool(leftInt > rightInt)
+		return 0, object.AsBool(left > right)
 	case "<":
-		return object.AsBool(leftInt < rightInt)
+		return 0, object.AsBool(left < right)
 	case "==":
-		return object.AsBool(leftInt == rightInt)
+		return 0, object.AsBool(left == right)
 	case "!=":
-		return object.AsBool(leftInt != rightInt)
+		return 0, object.AsBool(left != right)
+	default:
+		return 0, object.FormatError("unknown operator: %s %s %s",
+			object.OBJ_INTEGER, operator, object.OBJ_INTEGER)
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.OBJ_INTEGER || obj.Type() == object.OBJ_FLOAT
+}
+
+// toFloat promotes an integer operand for mixed arithmetic.
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+func evalFloatInfixExp(left, right object.Object, operator string) object.Object {
+	leftFloat := toFloat(left)
+	rightFloat := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftFloat + rightFloat}
+	case "-":
+		return &object.Float{Value: leftFloat - rightFloat}
+	case "*":
+		return &object.Float{Value: leftFloat * rightFloat}
+	case "/":
+		return &object.Float{Value: leftFloat / rightFloat}
+	case ">":
+		return object.AsBool(leftFloat > rightFloat)
+	case "<":
+		return object.AsBool(leftFloat < rightFloat)
+	case "==":
+		return object.AsBool(leftFloat == rightFloat)
+	case "!=":
+		return object.AsBool(leftFloat != rightFloat)
+	default:
+		return object.FormatError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalStringInfixExp(left, right object.Object, operator string) object.Object {
+	leftStr := left.(*object.String).Value
+	rightStr := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftStr + rightStr}
+	case "==":
+		return object.AsBool(leftStr == rightStr)
+	case "!=":
+		return object.AsBool(leftStr != rightStr)
 	default:
 		return object.FormatError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
-func evalIfExpression(ifExp *ast.IfExpression, env *object.Environment) object.Object {
-	cond := Eval(ifExp.Condition, env)
+func isTemporal(obj object.Object) bool {
+	return obj.Type() == object.OBJ_TIME || obj.Type() == object.OBJ_DURATION
+}
+
+// evalTimeInfixExp implements the arithmetic between times, durations and
+// integer factors, and comparisons between values of the same type.
+func evalTimeInfixExp(left, right object.Object, operator string) object.Object {
+	switch left := left.(type) {
+	case *object.Time:
+		switch right := right.(type) {
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Time{Value: left.Value.Add(right.Value)}
+			case "-":
+				return &object.Time{Value: left.Value.Add(-right.Value)}
+			}
+		case *object.Time:
+			if operator == "-" {
+				return &object.Duration{Value: left.Value.Sub(right.Value)}
+			}
+			if result, ok := compare(left.Value.Compare(right.Value), operator); ok {
+				return result
+			}
+		}
+
+	case *object.Duration:
+		switch right := right.(type) {
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: left.Value + right.Value}
+			case "-":
+				return &object.Duration{Value: left.Value - right.Value}
+			}
+			if result, ok := compare(cmp.Compare(left.Value, right.Value), operator); ok {
+				return result
+			}
+		case *object.Time:
+			if operator == "+" {
+				return &object.Time{Value: right.Value.Add(left.Value)}
+			}
+		case *object.Integer:
+			switch operator {
+			case "*":
+				return &object.Duration{Value: left.Value * time.Duration(right.Value)}
+			case "/":
+				if right.Value == 0 {
+					return object.FormatError("division by zero")
+				}
+				return &object.Duration{Value: left.Value / time.Duration(right.Value)}
+			}
+		}
+
+	case *object.Integer:
+		if right, ok := right.(*object.Duration); ok && operator == "*" {
+			return &object.Duration{Value: time.Duration(left.Value) * right.Value}
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return object.FormatError("type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+	return object.FormatError("unknown operator: %s %s %s",
+		left.Type(), operator, right.Type())
+}
+
+// compare turns the result of a three way comparison into the value of a
+// comparison operator.
+func compare(c int, operator string) (object.Object, bool) {
+	switch operator {
+	case "<":
+		return object.AsBool(c < 0), true
+	case ">":
+		return object.AsBool(c > 0), true
+	case "==":
+		return object.AsBool(c == 0), true
+	case "!=":
+		return object.AsBool(c != 0), true
+	default:
+		return nil, false
+	}
+}
 
+func (e *Evaluator) evalIfExpression(ifExp *ast.IfExpression, env *object.Environment) object.Object {
+	cond := e.Eval(ifExp.Condition, env)
+	if object.IsError(cond) {
+		return cond
+	}
+
+	// each branch gets its own scope so that its bindings do not leak
 	if object.IsTruthy(cond) {
-		return Eval(ifExp.Consequence, env)
+		return e.Eval(ifExp.Consequence, object.NewScope(env, ifExp.Consequence.Names))
 	}
 	if ifExp.Alternative != nil {
-		return Eval(ifExp.Alternative, env)
+		return e.Eval(ifExp.Alternative, object.NewScope(env, ifExp.Alternative.Names))
 	}
 	return object.NULL
 }
 
-func evalBlockStmt(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (e *Evaluator) evalBlockStmt(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range block.Statements {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 
 		if result == nil {
 			continue
@@ -201,3 +652,452 @@ func evalBlockStmt(block *ast.BlockStatement, env *object.Environment) object.Ob
 
 	return result
 }
+
+func (e *Evaluator) evalCallExp(call *ast.CallExpression, env *object.Environment) object.Object {
+	if dot, ok := call.Func.(*ast.DotExpression); ok {
+		return e.evalMethodCall(call, dot, env)
+	}
+
+	fn := e.Eval(call.Func, env)
+	if object.IsError(fn) {
+		return fn
+	}
+
+	args := e.evalExpressions(call.Args, env)
+	if len(args) == 1 && object.IsError(args[0]) {
+		return args[0]
+	}
+
+	return e.applyFunction(fn, args, call.Token.Pos)
+}
+
+func (e *Evaluator) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	result := make([]object.Object, 0, len(exps))
+
+	for _, exp := range exps {
+		val := e.Eval(exp, env)
+		if object.IsError(val) {
+			return []object.Object{val}
+		}
+		result = append(result, val)
+	}
+
+	return result
+}
+
+// Apply calls fn with args on behalf of the host.
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) object.Object {
+	if err := e.step(); err != nil {
+		return err
+	}
+	return e.applyFunction(fn, args, token.Position{})
+}
+
+func (e *Evaluator) applyFunction(
+	fn object.Object,
+	args []object.Object,
+	callSite token.Position,
+) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return object.FormatError("wrong number of arguments: want=%d, got=%d",
+				len(fn.Parameters), len(args))
+		}
+
+		caller := e.source
+		e.calls = append(e.calls, Call{Function: fn.DisplayName(), Source: fn.Source, Site: callSite})
+		e.source = fn.Source
+		defer func() {
+			e.calls = e.calls[:len(e.calls)-1]
+			e.source = caller
+		}()
+		if e.limits.MaxDepth > 0 && len(e.calls) > e.limits.MaxDepth {
+			return object.NewError(object.ERR_STACK_DEPTH)
+		}
+
+		// calls in tail position come back as a tailCall and are made here,
+		// in a loop, so that tail recursion does not grow the Go stack. The
+		// frames of the functions they replace are left out of traces.
+		var result object.Object
+		for {
+			result = unwrapReturnValue(e.evalBody(fn.Body, extendFunctionEnv(fn, args)))
+			tc, ok := result.(*tailCall)
+			if !ok {
+				break
+			}
+			if next, ok := tc.fn.(*object.Function); ok && len(tc.args) == len(next.Parameters) {
+				fn, args = next, tc.args
+				e.calls[len(e.calls)-1].Function = fn.DisplayName()
+				e.calls[len(e.calls)-1].Source = fn.Source
+				e.source = fn.Source
+				continue
+			}
+			result = locate(e.applyFunction(tc.fn, tc.args, tc.tok.Pos), tc.tok)
+			break
+		}
+		if result == nil {
+			// a body without statements has no value of its own
+			result = object.NULL
+		}
+		if err, ok := result.(*object.Error); ok {
+			err.Unwind(fn.DisplayName(), callSite)
+		}
+		return result
+
+	case *object.Builtin:
+		if fn.Allocating != nil {
+			// the builtin accounts for what it creates itself
+			return fn.Allocating(e.charge, args...)
+		}
+
+		var apply object.Applier
+		if fn.HigherOrder != nil {
+			apply = func(callback object.Object, args ...object.Object) object.Object {
+				return e.applyFunction(callback, args, callSite)
+			}
+		}
+
+		result := fn.Call(apply, args...)
+		if object.IsError(result) {
+			return result
+		}
+		return e.alloc(result)
+
+	default:
+		return object.FormatError("not a function: %s", fn.Type())
+	}
+}
+
+// tailCall is a call in tail position of a function body, to be made by
+// the applyFunction that evaluated the body once it has returned.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	tok  token.Token
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call to " + tc.fn.Inspect() }
+
+// evalBody evaluates the body of a function like Eval, except that calls in
+// tail position are returned as a tailCall instead of being made. A call is
+// in tail position if its value is the result of the function: it is
+// returned, or it is the last statement of the body or of an if branch in
+// tail position. Calls in try blocks never are, since the block has to
+// catch what they throw.
+func (e *Evaluator) evalBody(body *ast.BlockStatement, env *object.Environment) object.Object {
+	if err := e.enter(body, env); err != nil {
+		return err
+	}
+	return e.evalTailBlock(body, env, true)
+}
+
+// evalTailBlock evaluates block like evalBlockStmt, treating its last
+// statement as in tail position if tail is set.
+func (e *Evaluator) evalTailBlock(block *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
+	var result object.Object
+
+	for i, stmt := range block.Statements {
+		result = e.evalTailStmt(stmt, env, tail && i == len(block.Statements)-1)
+
+		if result == nil {
+			continue
+		}
+
+		switch result.Type() {
+		case object.OBJ_RETURN_VALUE:
+			fallthrough
+		case object.OBJ_ERROR:
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalTailStmt evaluates a statement of a function body. Return statements
+// are always in tail position, other statements only if tail is set, but
+// the if expressions they consist of may contain returns.
+func (e *Evaluator) evalTailStmt(stmt ast.Statement, env *object.Environment, tail bool) object.Object {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStatement:
+		if err := e.enter(stmt, env); err != nil {
+			return err
+		}
+		val := e.evalTailExp(stmt.ReturnValue, env)
+		if object.IsError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+
+	case *ast.ExpressionStatement:
+		if ifExp, ok := stmt.Expression.(*ast.IfExpression); ok || tail {
+			if err := e.enter(stmt, env); err != nil {
+				return err
+			}
+			if ok && !tail {
+				if err := e.enter(ifExp, env); err != nil {
+					return err
+				}
+				return e.evalTailIf(ifExp, env, false)
+			}
+			return e.evalTailExp(stmt.Expression, env)
+		}
+	}
+	return e.Eval(stmt, env)
+}
+
+// evalTailExp evaluates an expression in tail position.
+func (e *Evaluator) evalTailExp(exp ast.Expression, env *object.Environment) object.Object {
+	switch exp := exp.(type) {
+	case *ast.IfExpression:
+		if err := e.enter(exp, env); err != nil {
+			return err
+		}
+		return e.evalTailIf(exp, env, true)
+
+	case *ast.CallExpression:
+		if _, ok := exp.Func.(*ast.DotExpression); ok {
+			break
+		}
+		if err := e.enter(exp, env); err != nil {
+			return err
+		}
+
+		fn := e.Eval(exp.Func, env)
+		if object.IsError(fn) {
+			return locate(fn, exp.Token)
+		}
+
+		args := e.evalExpressions(exp.Args, env)
+		if len(args) == 1 && object.IsError(args[0]) {
+			return locate(args[0], exp.Token)
+		}
+
+		return &tailCall{fn: fn, args: args, tok: exp.Token}
+	}
+	return e.Eval(exp, env)
+}
+
+// evalTailIf evaluates an if expression like evalIfExpression, with the
+// branch taken in tail position if tail is set.
+func (e *Evaluator) evalTailIf(ifExp *ast.IfExpression, env *object.Environment, tail bool) object.Object {
+	cond := e.Eval(ifExp.Condition, env)
+	if object.IsError(cond) {
+		return cond
+	}
+
+	branch := ifExp.Alternative
+	if object.IsTruthy(cond) {
+		branch = ifExp.Consequence
+	}
+	if branch == nil {
+		return object.NULL
+	}
+	scope := object.NewScope(env, branch.Names)
+	if err := e.enter(branch, scope); err != nil {
+		return err
+	}
+	return e.evalTailBlock(branch, scope, tail)
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewScope(fn.Env, fn.Body.Names)
+
+	for i, param := range fn.Parameters {
+		env.SetAt(ownSlot(param), param.Value, args[i])
+	}
+
+	return env
+}
+
+// ownSlot returns the slot of the variable ident declares in the scope it
+// is declared in, or -1 to bind it by name if it is not resolved to one.
+func ownSlot(ident *ast.Identifier) int {
+	if !ident.Resolved {
+		return -1
+	}
+	return ident.Slot
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if rv, ok := obj.(*object.ReturnValue); ok {
+		return rv.Value
+	}
+	return obj
+}
+
+func (e *Evaluator) evalTryExp(te *ast.TryExpression, env *object.Environment) object.Object {
+	result := e.Eval(te.Block, object.NewScope(env, te.Block.Names))
+
+	// limits and cancellation must not be swallowed by the script itself
+	if err, ok := result.(*object.Error); ok && err.Kind.Fatal() {
+		return err
+	}
+
+	if err, ok := result.(*object.Error); ok && te.Catch != nil {
+		catchEnv := object.NewScope(env, te.Catch.Names)
+		if te.Param != nil {
+			catchEnv.SetAt(ownSlot(te.Param), te.Param.Value, err.Caught())
+		}
+		result = e.Eval(te.Catch, catchEnv)
+	}
+
+	if te.Finally != nil {
+		// finally only replaces the result when it aborts on its own
+		final := e.Eval(te.Finally, object.NewScope(env, te.Finally.Names))
+		if final != nil && (object.IsError(final) || final.Type() == object.OBJ_RETURN_VALUE) {
+			return final
+		}
+	}
+
+	if result == nil {
+		return object.NULL
+	}
+	return result
+}
+
+func (e *Evaluator) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := e.Eval(pair.Key, env)
+		if object.IsError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return object.FormatError("unusable as hash key: %s", key.Type())
+		}
+
+		value := e.Eval(pair.Value, env)
+		if object.IsError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return e.alloc(hash)
+}
+
+func (e *Evaluator) evalIndexExp(node *ast.IndexExpression, env *object.Environment) object.Object {
+	left := e.Eval(node.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+
+	index := e.Eval(node.Index, env)
+	if object.IsError(index) {
+		return index
+	}
+
+	switch {
+	case left.Type() == object.OBJ_ARRAY && index.Type() == object.OBJ_INTEGER:
+		return evalArrayIndexExp(left.(*object.Array), index.(*object.Integer))
+	case left.Type() == object.OBJ_HASH:
+		return evalHashIndexExp(left.(*object.Hash), index)
+	default:
+		return object.FormatError("index operator not supported: %s[%s]",
+			left.Type(), index.Type())
+	}
+}
+
+func evalArrayIndexExp(array *object.Array, index *object.Integer) object.Object {
+	if index.Value < 0 || index.Value >= int64(len(array.Elements)) {
+		return object.NULL
+	}
+	return array.Elements[index.Value]
+}
+
+func evalHashIndexExp(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return object.FormatError("unusable as hash key: %s", index.Type())
+	}
+
+	if val, ok := hash.Get(key); ok {
+		return val
+	}
+	return object.NULL
+}
+
+func (e *Evaluator) evalDotExp(dot *ast.DotExpression, env *object.Environment) object.Object {
+	left := e.Eval(dot.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+
+	getter, ok := left.(object.Getter)
+	if !ok {
+		return object.FormatError("member access not supported: %s.%s",
+			left.Type(), dot.Name.Value)
+	}
+
+	member, err := getter.GetMember(dot.Name.Value)
+	if err != nil {
+		return object.FormatError("%s", err)
+	}
+	return member
+}
+
+func (e *Evaluator) assignMember(dot *ast.DotExpression, val object.Object, env *object.Environment) object.Object {
+	left := e.Eval(dot.Left, env)
+	if object.IsError(left) {
+		return left
+	}
+
+	setter, ok := left.(object.Setter)
+	if !ok {
+		return object.FormatError("member assignment not supported: %s.%s",
+			left.Type(), dot.Name.Value)
+	}
+
+	if err := setter.SetMember(dot.Name.Value, val); err != nil {
+		return object.FormatError("%s", err)
+	}
+	return val
+}
+
+// evalMethodCall dispatches obj.name(args) to receivers implementing
+// object.Caller and otherwise calls whatever obj.name evaluates to.
+func (e *Evaluator) evalMethodCall(
+	call *ast.CallExpression,
+	dot *ast.DotExpression,
+	env *object.Environment,
+) object.Object {
+	receiver := e.Eval(dot.Left, env)
+	if object.IsError(receiver) {
+		return receiver
+	}
+
+	args := e.evalExpressions(call.Args, env)
+	if len(args) == 1 && object.IsError(args[0]) {
+		return args[0]
+	}
+
+	if caller, ok := receiver.(object.Caller); ok {
+		result, err := caller.CallMember(dot.Name.Value, args...)
+		if err != nil {
+			return object.FormatError("%s", err)
+		}
+		if result == nil {
+			return object.NULL
+		}
+		return e.alloc(result)
+	}
+
+	getter, ok := receiver.(object.Getter)
+	if !ok {
+		return object.FormatError("member access not supported: %s.%s",
+			receiver.Type(), dot.Name.Value)
+	}
+
+	fn, err := getter.GetMember(dot.Name.Value)
+	if err != nil {
+		return object.FormatError("%s", err)
+	}
+	return e.applyFunction(fn, args, call.Token.Pos)
+}
@@ -1,10 +1,15 @@
 package eval
 
 import (
+	"context"
+	"fmt"
+	"monkey/ast"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -193,6 +198,9 @@ if (10 > 1) {
 	return 1;
 }`, "unknown operator: BOOLEAN + BOOLEAN"},
 		{"foobar", "identifier not found: foobar"},
+		{"let f = fn() {}; f() + 1", "type mismatch: NULL + INTEGER"},
+		{"1 + if (true) {}", "type mismatch: INTEGER + NULL"},
+		{"if (true) {} * (2 + 3)", "type mismatch: NULL * INTEGER"},
 	}
 
 	for _, tt := range tests {
@@ -221,9 +229,806 @@ func TestLetStatements(t *testing.T) {
 		{"let a = 5 * 5; a;", 25},
 		{"let a = 5; let b = a; b;", 5},
 		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let a: int = 5; let f = fn(x: int): int { x * 2 }; f(a);", 10},
 	}
 
 	for _, tt := range tests {
 		testIntegerObject(t, testEval(tt.input), tt.expected)
 	}
 }
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let b = a * 2; b;", 10},
+		{"const a = 5; a = 6;", "cannot assign to constant: a"},
+		{"const a = 5; let a = 6;", "cannot redeclare constant: a"},
+		{"const a = 5; const a = 6;", "cannot redeclare constant: a"},
+		{"const a = 5; if (true) { let a = 6; a }", 6},
+		{"const a = 5; if (true) { a = 6; }", "cannot assign to constant: a"},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let a = 5; a = 6; a;", 6},
+		{"let a = 5; a = a * 2;", 10},
+		{"let a = 1; let b = 2; a = b = 3; a + b;", 6},
+		{"let a = 5; if (true) { a = 6; } a;", 6},
+		{"a = 5;", "identifier not found: a"},
+		{"let a = 5; a = true + 1;", "type mismatch: BOOLEAN + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"if (true) { let a = 5; } a;", "identifier not found: a"},
+		{"if (false) { 1 } else { let a = 5; } a;", "identifier not found: a"},
+		{"let a = 1; if (true) { let a = 5; } a;", 1},
+		{"let a = 1; if (true) { let a = 5; a }", 5},
+		{"let a = 1; if (true) { if (true) { a } }", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRedeclaration(t *testing.T) {
+	input := "let a = 1; let a = 2; a;"
+
+	testIntegerObject(t, testEval(input), 2)
+
+	var redeclared []string
+	env := object.NewEnvironment()
+	env.OnRedeclare(func(name string) *object.Error {
+		redeclared = append(redeclared, name)
+		return nil
+	})
+	testIntegerObject(t, Eval(parser.New(lexer.New(input)).ParseProgram(), env), 2)
+	if len(redeclared) != 1 || redeclared[0] != "a" {
+		t.Errorf("handler should have been called once for a, got=%v", redeclared)
+	}
+
+	env = object.NewEnvironment()
+	env.OnRedeclare(object.ForbidRedeclare)
+	testIntegerOrError(t, Eval(parser.New(lexer.New(input)).ParseProgram(), env),
+		"identifier already declared: a")
+
+	env = object.NewEnvironment()
+	env.OnRedeclare(object.ForbidRedeclare)
+	shadowed := "let a = 1; if (true) { let a = 2; a }"
+	testIntegerObject(t, Eval(parser.New(lexer.New(shadowed)).ParseProgram(), env), 2)
+}
+
+func testIntegerOrError(t *testing.T, obj object.Object, expected any) bool {
+	switch expected := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(expected))
+	case string:
+		err, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", obj, obj)
+			return false
+		}
+		if err.Msg != expected {
+			t.Errorf("wrong error message, expected=%q, got=%q", expected, err.Msg)
+			return false
+		}
+		return true
+	default:
+		t.Errorf("type of expected not handled, got=%T", expected)
+		return false
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not a Function, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters, got=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x', got=%q", fn.Parameters[0])
+	}
+
+	if fn.Body.String() != "(x + 2)" {
+		t.Fatalf("body is not %q, got=%q", "(x + 2)", fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) }; fact(5);", 120},
+		{"let add = fn(x, y) { x + y; }; add(1);", "wrong number of arguments: want=2, got=1"},
+		{"let x = 5; x(1);", "not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEmptyFunctionBody(t *testing.T) {
+	tests := []string{
+		"fn() {}()",
+		"let f = fn() {}; f()",
+		"let f = fn() { if (true) {} }; f()",
+		"let f = fn() {}; let g = fn() { f() }; g()",
+	}
+
+	for _, input := range tests {
+		testNullObject(t, testEval(input))
+	}
+
+	// the result has to be inspectable inside other objects too
+	result := testEval("let f = fn() {}; [f(), {1: f()}]")
+	if got := result.Inspect(); got != "[null, {1: null}]" {
+		t.Errorf("wrong result, got=%s", got)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+let newAdder = fn(x) {
+	fn(y) { x + y };
+};
+
+let addTwo = newAdder(2);
+addTwo(2);`
+
+	testIntegerObject(t, testEval(input), 4)
+}
+
+func TestStringLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"Hello World!"`, "Hello World!"},
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" == "b"`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+
+	testIntegerOrError(t, testEval(`"a" - "b"`), "unknown operator: STRING - STRING")
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+	str, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not a String, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if str.Value != expected {
+		t.Errorf("str.Value should be %q, but got=%q", expected, str.Value)
+		return false
+	}
+	return true
+}
+
+func TestTryCatchFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"try { 1 } catch (e) { 2 }", 1},
+		{`try { throw("boom"); 1 } catch (e) { 2 }`, 2},
+		{`try { throw(42) } catch (e) { e + 1 }`, 43},
+		{`try { throw("boom") } catch { 7 }`, 7},
+		{`let a = 0; try { 1 } finally { a = 5 }; a`, 5},
+		{`let a = 0; try { throw("x") } catch (e) { 1 } finally { a = 5 }; a`, 5},
+		{`try { 1 } finally { 2 }`, 1},
+		{`try { throw("x") } finally { 2 }`, "x"},
+		{`try { 1 } finally { throw("from finally") }`, "from finally"},
+		{`try { throw("a") } catch (e) { throw("b") }`, "b"},
+		{`try { 1 } catch (e) { 2 }; e`, "identifier not found: e"},
+		{`try { let a = 1; } catch (e) { 2 }; a`, "identifier not found: a"},
+		{`let f = fn() { try { return 1; } finally { 2 }; 3 }; f()`, 1},
+		{`let f = fn() { throw("inner") }; try { f() } catch (e) { 9 }`, 9},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+
+	caught := []struct {
+		input    string
+		expected string
+	}{
+		{`try { 1 + true } catch (e) { e }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`try { foo } catch (e) { e }`, "identifier not found: foo"},
+		{`try { throw("boom") } catch (e) { e + "!" }`, "boom!"},
+		{`try { throw() } catch (e) { e }`, "wrong number of arguments: want=1, got=0"},
+	}
+
+	for _, tt := range caught {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let inner = fn() {
+	1 + true
+};
+let outer = fn() {
+	inner() + 1
+};
+outer();`
+
+	err, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+
+	if err.Pos.String() != "2:4" {
+		t.Errorf("err.Pos should be 2:4, got=%s", err.Pos)
+	}
+
+	expected := []string{"at inner (2:4)", "at outer (5:7)"}
+	if len(err.Trace) != len(expected) {
+		t.Fatalf("trace should have %d frames, got=%v", len(expected), err.Trace)
+	}
+	for i, frame := range expected {
+		if err.Trace[i].String() != frame {
+			t.Errorf("frame %d should be %q, got=%q", i, frame, err.Trace[i])
+		}
+	}
+
+	inspected := "ERROR: type mismatch: INTEGER + BOOLEAN\n" +
+		"\tat inner (2:4)\n\tat outer (5:7)\n\tat <main> (7:6)"
+	if err.Inspect() != inspected {
+		t.Errorf("err.Inspect() wrong, expected=%q, got=%q", inspected, err.Inspect())
+	}
+
+	anonymous := testEval(`fn() { throw("x") }()`).(*object.Error)
+	if len(anonymous.Trace) != 1 || anonymous.Trace[0].Function != "<anonymous>" {
+		t.Errorf("expected an <anonymous> frame, got=%v", anonymous.Trace)
+	}
+}
+
+func TestEvalLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   Limits
+		expected object.ErrorKind
+	}{
+		{"let f = fn() { 1 + f() }; f()", Limits{MaxDepth: 100}, object.ERR_STACK_DEPTH},
+		{"let f = fn(n) { n + f(n + 1) }; f(0)", Limits{MaxDepth: 50}, object.ERR_STACK_DEPTH},
+		{"1 + 2 + 3 + 4 + 5 + 6", Limits{MaxSteps: 5}, object.ERR_STEP_LIMIT},
+		{"let f = fn() { f() }; f()", Limits{MaxSteps: 1000}, object.ERR_STEP_LIMIT},
+		{"let f = fn() { 1 + f() }; try { f() } catch (e) { 1 }", Limits{MaxDepth: 10}, object.ERR_STACK_DEPTH},
+		{"let f = fn() { f() }; try { f() } finally { 1 }", Limits{MaxSteps: 100}, object.ERR_STEP_LIMIT},
+		// without a depth limit, the default one applies
+		{"let f = fn() { 1 + f() }; f()", Limits{}, object.ERR_STACK_DEPTH},
+		{"let f = fn() { 1 + f() }; f()", Limits{MaxSteps: 1 << 30}, object.ERR_STACK_DEPTH},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := EvalContext(context.Background(), program, object.NewEnvironment(), tt.limits)
+
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)",
+				tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Kind != tt.expected || err.Msg != tt.expected.String() {
+			t.Errorf("wrong error for %q, expected=%q, got=%q",
+				tt.input, tt.expected, err.Msg)
+		}
+	}
+
+	within := "let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(10)"
+	program := parser.New(lexer.New(within)).ParseProgram()
+	evaluated := EvalContext(context.Background(), program, object.NewEnvironment(),
+		Limits{MaxSteps: 1000, MaxDepth: 11})
+	testIntegerObject(t, evaluated, 0)
+}
+
+func TestArithmeticErrorPosition(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   string
+	}{
+		{"1 + 2 * (3 / 0)", "1:12"},
+		{"(1 + 2) * (3 - true) + 4", "1:14"},
+		{"let x = 1; x * 2 + \"a\"", "1:18"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if err.Pos.String() != tt.pos {
+			t.Errorf("wrong position for %q, expected=%s, got=%s", tt.input, tt.pos, err.Pos)
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(100000)", 0},
+		{"let loop = fn(n, acc) { if (n == 0) { return acc; } return loop(n - 1, acc + n); }; loop(100000, 0)", 5000050000},
+		{"let loop = fn(n) { if (n > 0) { return loop(n - 1); } 7 }; loop(100000)", 7},
+		{`let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+if (even(100001)) { 1 } else { 0 }`, 0},
+		{"let loop = fn(n) { if (n == 0) { reduce([1, 2], fn(a, b) { a + b }) } else { loop(n - 1) } }; loop(1000)", 3},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := EvalContext(context.Background(), program, object.NewEnvironment(),
+			Limits{MaxDepth: 10})
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+	// calls in try blocks and operands are not in tail position
+	notTail := []string{
+		"let f = fn(n) { if (n == 0) { 0 } else { 0 + f(n - 1) } }; f(100)",
+		"let f = fn(n) { if (n == 0) { 0 } else { try { f(n - 1) } catch (e) { e } } }; f(100)",
+	}
+	for _, input := range notTail {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		evaluated := EvalContext(context.Background(), program, object.NewEnvironment(),
+			Limits{MaxDepth: 10})
+		testErrorKind(t, evaluated, object.ERR_STACK_DEPTH)
+	}
+
+	// the frames of the functions tail calls replace are left out
+	err, ok := testEval(`let inner = fn() { 1 + true };
+let outer = fn() { inner() };
+outer()`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	inspected := "ERROR: type mismatch: INTEGER + BOOLEAN\n" +
+		"\tat inner (1:22)\n\tat <main> (3:6)"
+	if err.Inspect() != inspected {
+		t.Errorf("err.Inspect() wrong, expected=%q, got=%q", inspected, err.Inspect())
+	}
+
+	arity, ok := testEval("let f = fn(a) { a }; let g = fn() { f() }; g()").(*object.Error)
+	if !ok || arity.Pos.String() != "1:38" || len(arity.Trace) != 1 || arity.Trace[0].Function != "g" {
+		t.Errorf("wrong error for a tail call with too few arguments, got=%v", arity)
+	}
+}
+
+func TestHook(t *testing.T) {
+	input := `let inner = fn() { 1 };
+let outer = fn() { inner() + 0 };
+let tail = fn() { inner() };
+outer();
+tail();`
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	e := New(context.Background(), Limits{})
+	var calls [][]string
+	e.Hook = func(node ast.Node, env *object.Environment) *object.Error {
+		if lit, ok := node.(*ast.IntegerLiteral); ok && lit.Value == 1 {
+			var names []string
+			for _, call := range e.Calls() {
+				names = append(names, call.Function)
+			}
+			calls = append(calls, names)
+		}
+		return nil
+	}
+	e.Eval(program, object.NewEnvironment())
+
+	expected := [][]string{{"outer", "inner"}, {"inner"}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("wrong calls seen by the hook, expected=%v, got=%v", expected, calls)
+	}
+
+	e.Hook = func(node ast.Node, env *object.Environment) *object.Error {
+		return object.NewError(object.ERR_CANCELLED)
+	}
+	testErrorKind(t, e.Eval(program, object.NewEnvironment()), object.ERR_CANCELLED)
+}
+
+func TestEvalCancellation(t *testing.T) {
+	program := parser.New(lexer.New("1 + 1")).ParseProgram()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testErrorKind(t, EvalContext(ctx, program, object.NewEnvironment(), Limits{}),
+		object.ERR_CANCELLED)
+
+	fib := `
+let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+try { fib(100) } catch (e) { 0 }`
+	program = parser.New(lexer.New(fib)).ParseProgram()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	testErrorKind(t, EvalContext(ctx, program, object.NewEnvironment(), Limits{}),
+		object.ERR_CANCELLED)
+}
+
+func testErrorKind(t *testing.T, obj object.Object, expected object.ErrorKind) bool {
+	err, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("no error object returned. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if err.Kind != expected {
+		t.Errorf("wrong error kind, expected=%q, got=%q", expected, err.Kind)
+		return false
+	}
+	return true
+}
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input     string
+		maxMemory int64
+		expected  any
+	}{
+		{`let s = "aaaaaaaaaa"; s + s + s + s + s + s + s + s`, 100, "memory limit exceeded"},
+		{`let f = fn(s) { f(s + s) }; f("a")`, 10000, "memory limit exceeded"},
+		{`let s = "aaaaaaaaaa"; try { s + s + s + s + s + s + s + s } catch (e) { 1 }`, 100, 1},
+		{`let s = "aaaaaaaaaa"; s + s; 5`, 1000, 5},
+		{`let f = fn(x) { fn(y) { x + y } }; f(1)(2)`, 1000, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalLimited(tt.input, Limits{MaxMemory: tt.maxMemory})
+		testIntegerOrError(t, evaluated, tt.expected)
+	}
+
+	testErrorKind(t, testEvalLimited(`"aaaa" + "aaaa"`, Limits{MaxMemory: 30}),
+		object.ERR_MEMORY_LIMIT)
+}
+
+func TestPeakMemory(t *testing.T) {
+	program := parser.New(lexer.New(`let s = "abcd"; let t = s + s; fn(x) { x }`)).ParseProgram()
+	evaluator := New(context.Background(), Limits{})
+	evaluator.Eval(program, object.NewEnvironment())
+
+	// "abcd", "abcdabcd" and a closure over one parameter
+	expected := int64((16 + 4) + (16 + 8) + (16 + 2*8))
+	if evaluator.PeakMemory() != expected {
+		t.Errorf("PeakMemory() should be %d, got=%d", expected, evaluator.PeakMemory())
+	}
+
+	limited := New(context.Background(), Limits{MaxMemory: 50})
+	limited.Eval(program, object.NewEnvironment())
+	if limited.PeakMemory() > 50 {
+		t.Errorf("PeakMemory() should never exceed the limit, got=%d", limited.PeakMemory())
+	}
+}
+
+func TestDefaultMaxDepth(t *testing.T) {
+	deep := "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(%d)"
+
+	testIntegerOrError(t, testEval(fmt.Sprintf(deep, DefaultMaxDepth-1)), DefaultMaxDepth-1)
+	testErrorKind(t, testEval(fmt.Sprintf(deep, DefaultMaxDepth)), object.ERR_STACK_DEPTH)
+	// a negative MaxDepth lifts the limit
+	unbounded := testEvalLimited(fmt.Sprintf(deep, 2*DefaultMaxDepth), Limits{MaxDepth: -1})
+	testIntegerOrError(t, unbounded, 2*DefaultMaxDepth)
+}
+
+func testEvalLimited(input string, limits Limits) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	return EvalContext(context.Background(), program, object.NewEnvironment(), limits)
+}
+
+func TestFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"2.5", 2.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"7 / 2.0", 3.5},
+		{"1.0 / 0", "+Inf"},
+		{"1.5 < 2", true},
+		{"2 == 2.0", true},
+		{"2.5 != 2.5", false},
+		{"7 / 2", 3},
+		{"1 / 0", "division by zero"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if f, ok := evaluated.(*object.Float); ok {
+				if f.Inspect() != expected {
+					t.Errorf("float should inspect as %s, got=%s", expected, f.Inspect())
+				}
+				continue
+			}
+			testIntegerOrError(t, evaluated, expected)
+		default:
+			testIntegerOrError(t, evaluated, expected)
+		}
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not a Float, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object was expected to have value of %g, got=%g",
+			expected, result.Value)
+		return false
+	}
+	return true
+}
+
+func TestArrayLiterals(t *testing.T) {
+	evaluated := testEval("[1, 2 * 2, 3 + 3]")
+
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not an Array, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong number of elements, got=%d", len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+		{`[1, 2, 3]["a"]`, "index operator not supported: ARRAY[STRING]"},
+		{`1[0]`, "index operator not supported: INTEGER[INTEGER]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.expected == nil {
+			testNullObject(t, evaluated)
+			continue
+		}
+		testIntegerOrError(t, evaluated, tt.expected)
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+{
+	"one": 10 - 9,
+	two: 1 + 1,
+	"thr" + "ee": 6 / 2,
+	4: 4,
+	true: 5,
+	false: 6
+}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("eval didn't return Hash, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{object.AsInt(4), 4},
+		{object.TRUE, 5},
+		{object.FALSE, 6},
+	}
+
+	if result.Len() != len(expected) {
+		t.Fatalf("hash has wrong num of pairs, got=%d", result.Len())
+	}
+
+	for i, pair := range result.Pairs() {
+		if pair.Key.(object.Hashable).HashKey() != expected[i].key.HashKey() {
+			t.Errorf("pair %d has the wrong key, got=%s", i, pair.Key.Inspect())
+		}
+		testIntegerObject(t, pair.Value, expected[i].value)
+	}
+
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("hash inspects wrong, got=%q", result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{"a": 1}[fn(x) { x }]`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 1}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.expected == nil {
+			testNullObject(t, evaluated)
+			continue
+		}
+		testIntegerOrError(t, evaluated, tt.expected)
+	}
+}
+
+type testUser struct {
+	Name  string `monkey:"name"`
+	Score int    `monkey:"score"`
+}
+
+func (u *testUser) Greet(greeting string) string {
+	return greeting + ", " + u.Name
+}
+
+func TestDotExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`user.name`, "ann"},
+		{`user.Greet("hi")`, "hi, ann"},
+		{`let greet = user.Greet; greet("hey")`, "hey, ann"},
+		{`user.score = user.score + 1; user.score`, 11},
+		{`user.score = "x"`, "cannot convert STRING to int"},
+		{`user.missing`, "*eval.testUser has no member missing"},
+		{`user.name(1)`, "not a function: STRING"},
+		{`let x = 1; x.y`, "member access not supported: INTEGER.y"},
+		{`let x = 1; x.y(1)`, "member access not supported: INTEGER.y"},
+		{`let x = 1; x.y = 2`, "member assignment not supported: INTEGER.y"},
+		{`try { user.Greet(1) } catch (e) { e }`, "argument 1: cannot convert INTEGER to string"},
+	}
+
+	for _, tt := range tests {
+		user := &testUser{Name: "ann", Score: 10}
+		env := object.NewEnvironment()
+		env.Set("user", object.NewHostObject(user))
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+			if user.Score != expected {
+				t.Errorf("user.Score not updated, got=%d", user.Score)
+			}
+		case string:
+			if str, ok := evaluated.(*object.String); ok {
+				testStringObject(t, str, expected)
+				continue
+			}
+			testIntegerOrError(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTimeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`start + hour`, "2024-01-01T13:00:00Z"},
+		{`hour + start`, "2024-01-01T13:00:00Z"},
+		{`start - hour`, "2024-01-01T11:00:00Z"},
+		{`(start + hour * 3) - start`, "3h0m0s"},
+		{`2 * hour + hour / 2`, "2h30m0s"},
+		{`hour - 2 * hour`, "-1h0m0s"},
+		{`-hour`, "-1h0m0s"},
+		{`start + hour > start`, "true"},
+		{`start < start - hour`, "false"},
+		{`start == start + hour - hour`, "true"},
+		{`hour != hour * 1`, "false"},
+		{`hour > hour / 2`, "true"},
+		{`hour / 0`, "division by zero"},
+		{`start + start`, "unknown operator: TIME + TIME"},
+		{`start * 2`, "type mismatch: TIME * INTEGER"},
+		{`hour + 1`, "type mismatch: DURATION + INTEGER"},
+		{`start == 1`, "type mismatch: TIME == INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("start", &object.Time{Value: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)})
+		env.Set("hour", &object.Duration{Value: time.Hour})
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if err, ok := evaluated.(*object.Error); ok {
+			if err.Msg != tt.expected {
+				t.Errorf("%s: wrong error, expected=%q, got=%q", tt.input, tt.expected, err.Msg)
+			}
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
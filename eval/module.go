@@ -0,0 +1,135 @@
+package eval
+
+import (
+	"io/fs"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/std"
+	"monkey/token"
+	"path"
+	"strings"
+)
+
+// ModuleExt is appended by FSLoader to import paths without an extension.
+const ModuleExt = ".mk"
+
+// Loader returns the source of the module imported as path.
+type Loader interface {
+	Load(path string) (string, error)
+}
+
+// FSLoader loads modules from the files of FS. Import paths are slash
+// separated and relative to the root of FS.
+type FSLoader struct {
+	FS fs.FS
+}
+
+func (l FSLoader) Load(name string) (string, error) {
+	if path.Ext(name) == "" {
+		name += ModuleExt
+	}
+
+	src, err := fs.ReadFile(l.FS, name)
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+// Modules evaluates imported modules and caches them, so every module runs
+// once no matter how often it is imported. Share one Modules between
+// evaluators to keep the cache across runs. It is not safe for concurrent
+// use.
+type Modules struct {
+	loader  Loader
+	cache   map[string]*object.Module
+	loading []string
+}
+
+func NewModules(loader Loader) *Modules {
+	return &Modules{
+		loader: loader,
+		cache:  make(map[string]*object.Module),
+	}
+}
+
+// moduleKey returns the key the module imported as name is cached under,
+// the same whether or not name ends in ModuleExt.
+func moduleKey(name string) string {
+	if path.Ext(name) == "" {
+		name += ModuleExt
+	}
+	return name
+}
+
+// load returns the module imported as name at pos, evaluating it with e
+// unless it is cached.
+func (m *Modules) load(e *Evaluator, name string, pos token.Position) object.Object {
+	name = path.Clean(name)
+	key := moduleKey(name)
+	if mod, ok := m.cache[key]; ok {
+		return mod
+	}
+
+	for i, loading := range m.loading {
+		if moduleKey(loading) == key {
+			chain := append(m.loading[i:len(m.loading):len(m.loading)], name)
+			return object.FormatError("import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	src, err := m.loader.Load(name)
+	if err != nil {
+		return object.FormatError("cannot import %s: %s", name, err)
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return object.FormatError("cannot import %s: %s", name, strings.Join(errors, "; "))
+	}
+
+	m.loading = append(m.loading, name)
+	defer func() { m.loading = m.loading[:len(m.loading)-1] }()
+
+	// the module runs like a call, so that debuggers can tell its code
+	// from the importer's
+	function, importer := "<module "+name+">", e.source
+	e.calls = append(e.calls, Call{Function: function, Source: name, Site: pos})
+	e.source = name
+	defer func() {
+		e.calls = e.calls[:len(e.calls)-1]
+		e.source = importer
+	}()
+
+	env := object.NewEnvironment()
+	if err, ok := e.Eval(program, env).(*object.Error); ok {
+		err.Unwind(function, pos)
+		return err
+	}
+
+	mod := &object.Module{Name: name, Env: env}
+	m.cache[key] = mod
+	return mod
+}
+
+func (e *Evaluator) evalImportStmt(is *ast.ImportStatement, env *object.Environment) object.Object {
+	if mod, ok := e.Imports[is.Path]; ok {
+		return env.DeclareAt(ownSlot(is.Name), is.Name.Value, mod, true)
+	}
+	if mod, ok := std.Lookup(is.Path); ok {
+		return env.DeclareAt(ownSlot(is.Name), is.Name.Value, mod, true)
+	}
+
+	if e.Modules == nil {
+		return object.FormatError("imports are not enabled: %s", is.Path)
+	}
+
+	mod := e.Modules.load(e, is.Path, is.Token.Pos)
+	if object.IsError(mod) {
+		return mod
+	}
+	return env.DeclareAt(ownSlot(is.Name), is.Name.Value, mod, true)
+}
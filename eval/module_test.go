@@ -0,0 +1,173 @@
+package eval
+
+import (
+	"context"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+var testModules = fstest.MapFS{
+	"calc.mk": {Data: []byte(`
+let square = fn(x) { x * x };
+const answer = 42;
+let _hidden = 1;
+`)},
+	"lib/greet.mk": {Data: []byte(`
+import "calc";
+let greet = fn(name) { "hello " + name };
+let answer = calc.answer;
+`)},
+	"counter.mk": {Data: []byte(`
+let count = 0;
+count = count + 1;
+`)},
+	"broken.mk": {Data: []byte(`let x = ;`)},
+	"fails.mk": {Data: []byte(`let f = fn() { 1 + true };
+f();`)},
+	"cycle/a.mk": {Data: []byte(`import "cycle/b"`)},
+	"cycle/b.mk": {Data: []byte(`import "cycle/c"`)},
+	"cycle/c.mk": {Data: []byte(`import "cycle/a"`)},
+	"self.mk":    {Data: []byte(`import "self.mk"`)},
+}
+
+func testEvalModules(input string, modules *Modules) object.Object {
+	e := New(context.Background(), Limits{})
+	e.Modules = modules
+	return e.Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment())
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import "calc"; calc.square(3)`, 9},
+		{`import "calc.mk"; calc.answer`, 42},
+		{`import "calc" as m; m.square(m.answer)`, 1764},
+		{`import "./calc" as m; m._hidden`, 1},
+		{`import "lib/greet"; greet.greet("bob")`, "hello bob"},
+		{`import "lib/greet"; greet.answer`, 42},
+		{`import "calc"; calc.missing`, "module calc has no member missing"},
+		{`import "calc"; calc.answer = 1`, "member assignment not supported: MODULE.answer"},
+		{`import "calc"; calc = 1`, "cannot assign to constant: calc"},
+		{`import "nope"`, "cannot import nope: open nope.mk: file does not exist"},
+		{`import "broken"`, "cannot import broken: no prefix parser for ; has been found"},
+		{`import "fails"`, "type mismatch: INTEGER + BOOLEAN"},
+		{`import "cycle/a"`, "import cycle: cycle/a -> cycle/b -> cycle/c -> cycle/a"},
+		{`import "self"`, "import cycle: self -> self.mk"},
+		{`try { import "nope" } catch (e) { 1 }`, 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalModules(tt.input, NewModules(FSLoader{FS: testModules}))
+		if str, ok := evaluated.(*object.String); ok {
+			testStringObject(t, str, tt.expected.(string))
+			continue
+		}
+		testIntegerOrError(t, evaluated, tt.expected)
+	}
+}
+
+func TestImportCache(t *testing.T) {
+	modules := NewModules(FSLoader{FS: testModules})
+
+	testIntegerObject(t, testEvalModules(`import "counter"; counter.count`, modules), 1)
+	testIntegerObject(t, testEvalModules(`import "counter"; import "counter" as c; c.count`, modules), 1)
+
+	// the extension is optional, with or without it the module is the same
+	loader := &countingLoader{Loader: FSLoader{FS: testModules}}
+	testEvalModules(`import "calc"; import "./calc.mk" as c; import "lib/greet"`, NewModules(loader))
+	if loader.loads != 2 {
+		t.Errorf("expected calc and lib/greet to be loaded once each, got=%d loads", loader.loads)
+	}
+
+	// a failed import is not cached and is retried on the next import
+	fsys := fstest.MapFS{"late.mk": {Data: []byte(`let x = y;`)}}
+	modules = NewModules(FSLoader{FS: fsys})
+	testIntegerOrError(t, testEvalModules(`import "late"`, modules), "identifier not found: y")
+	fsys["late.mk"] = &fstest.MapFile{Data: []byte(`let x = 1;`)}
+	testIntegerObject(t, testEvalModules(`import "late"; late.x`, modules), 1)
+}
+
+type countingLoader struct {
+	Loader
+	loads int
+}
+
+func (l *countingLoader) Load(name string) (string, error) {
+	l.loads++
+	return l.Loader.Load(name)
+}
+
+func TestImportErrorTrace(t *testing.T) {
+	input := `let x = 1;
+import "fails";`
+
+	err, ok := testEvalModules(input, NewModules(FSLoader{FS: testModules})).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+
+	inspected := "ERROR: type mismatch: INTEGER + BOOLEAN\n" +
+		"\tat f (1:18)\n\tat <module fails> (2:2)\n\tat <main> (2:1)"
+	if err.Inspect() != inspected {
+		t.Errorf("err.Inspect() wrong, expected=%q, got=%q", inspected, err.Inspect())
+	}
+}
+
+func TestImportSource(t *testing.T) {
+	input := `import "calc";
+let f = fn(x) { x };
+calc.square(f(3));`
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	e := New(context.Background(), Limits{})
+	e.Modules = NewModules(FSLoader{FS: testModules})
+	var sources []string
+	var calls [][]Call
+	e.Hook = func(node ast.Node, env *object.Environment) *object.Error {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			sources = append(sources, e.Source())
+			calls = append(calls, e.Calls())
+		}
+		return nil
+	}
+	e.Eval(program, object.NewEnvironment())
+
+	expected := []string{"", "calc", "calc"}
+	if !slices.Equal(sources, expected) {
+		t.Errorf("wrong sources, expected=%q, got=%q", expected, sources)
+	}
+	square := []Call{{Function: "square", Source: "calc", Site: token.Position{Line: 3, Column: 12}}}
+	if !reflect.DeepEqual(calls[1], square) {
+		t.Errorf("wrong calls in the module, expected=%+v, got=%+v", square, calls[1])
+	}
+
+	e.Hook = func(node ast.Node, env *object.Environment) *object.Error {
+		if lit, ok := node.(*ast.IntegerLiteral); ok && lit.Value == 42 {
+			calls = append(calls, e.Calls())
+		}
+		return nil
+	}
+	e.Modules = NewModules(FSLoader{FS: testModules})
+	e.Eval(program, object.NewEnvironment())
+
+	loading := []Call{{Function: "<module calc>", Source: "calc", Site: token.Position{Line: 1, Column: 1}}}
+	if !reflect.DeepEqual(calls[len(calls)-1], loading) {
+		t.Errorf("wrong calls while importing, expected=%+v, got=%+v", loading, calls[len(calls)-1])
+	}
+	if e.Source() != "" {
+		t.Errorf("the source should be restored after evaluating, got=%q", e.Source())
+	}
+}
+
+func TestImportDisabled(t *testing.T) {
+	testIntegerOrError(t, testEval(`import "calc"`), "imports are not enabled: calc")
+}
@@ -0,0 +1,157 @@
+package eval
+
+import "monkey/ast"
+
+// resolve locates the variables the identifiers of program refer to, so
+// that they are looked up by index instead of by name. Every block opening
+// a scope at runtime gets the names of its variables, in slot order, and
+// every identifier the number of scopes out its variable is declared and
+// its slot there. Names not declared in a function or block are left to
+// the top-level scope, which is looked up by name since the host and
+// later programs share it.
+func resolve(program *ast.Program) {
+	r := &resolver{scope: &scope{}}
+	r.stmts(program.Statements)
+}
+
+type scope struct {
+	outer *scope
+	// names holds the variables of the scope by slot, nil for the top-level
+	// scope
+	names []string
+}
+
+type resolver struct {
+	scope *scope
+}
+
+// open enters the scope of block, whose variables are params and the names
+// declared by its statements, including those further down since
+// functions may refer to them before they are reached.
+func (r *resolver) open(block *ast.BlockStatement, params ...*ast.Identifier) {
+	names := make([]string, 0, len(params))
+	declare := func(name string) {
+		for _, n := range names {
+			if n == name {
+				return
+			}
+		}
+		names = append(names, name)
+	}
+
+	for _, param := range params {
+		declare(param.Value)
+	}
+	for _, stmt := range block.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			declare(stmt.Name.Value)
+		case *ast.ImportStatement:
+			declare(stmt.Name.Value)
+		}
+	}
+
+	block.Names = names
+	r.scope = &scope{outer: r.scope, names: names}
+}
+
+func (r *resolver) close() {
+	r.scope = r.scope.outer
+}
+
+// block resolves block in a scope of its own, binding params first.
+func (r *resolver) block(block *ast.BlockStatement, params ...*ast.Identifier) {
+	if block == nil {
+		return
+	}
+	r.open(block, params...)
+	for _, param := range params {
+		r.ident(param)
+	}
+	r.stmts(block.Statements)
+	r.close()
+}
+
+func (r *resolver) ident(ident *ast.Identifier) {
+	depth := 0
+	for s := r.scope; ; s = s.outer {
+		if s.outer == nil {
+			ident.Resolved, ident.Depth, ident.Slot = true, depth, -1
+			return
+		}
+		for slot, name := range s.names {
+			if name == ident.Value {
+				ident.Resolved, ident.Depth, ident.Slot = true, depth, slot
+				return
+			}
+		}
+		depth++
+	}
+}
+
+func (r *resolver) stmts(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			r.expr(stmt.Value)
+			r.ident(stmt.Name)
+		case *ast.ImportStatement:
+			r.ident(stmt.Name)
+		case *ast.ReturnStatement:
+			r.expr(stmt.ReturnValue)
+		case *ast.ExpressionStatement:
+			r.expr(stmt.Expression)
+		}
+	}
+}
+
+func (r *resolver) expr(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		r.ident(expr)
+	case *ast.AssignExpression:
+		r.expr(expr.Target)
+		r.expr(expr.Value)
+	case *ast.PrefixExpression:
+		r.expr(expr.Right)
+	case *ast.InfixExpression:
+		r.expr(expr.Left)
+		r.expr(expr.Right)
+	case *ast.IfExpression:
+		r.expr(expr.Condition)
+		r.block(expr.Consequence)
+		r.block(expr.Alternative)
+	case *ast.FunctionLiteral:
+		r.block(expr.Body, expr.Parameters...)
+	case *ast.CallExpression:
+		r.expr(expr.Func)
+		r.exprs(expr.Args)
+	case *ast.TryExpression:
+		r.block(expr.Block)
+		if expr.Param != nil {
+			r.block(expr.Catch, expr.Param)
+		} else {
+			r.block(expr.Catch)
+		}
+		r.block(expr.Finally)
+	case *ast.ArrayLiteral:
+		r.exprs(expr.Elements)
+	case *ast.IndexExpression:
+		r.expr(expr.Left)
+		r.expr(expr.Index)
+	case *ast.DotExpression:
+		// the name is a member, not a variable
+		r.expr(expr.Left)
+	case *ast.HashLiteral:
+		for _, pair := range expr.Pairs {
+			r.expr(pair.Key)
+			r.expr(pair.Value)
+		}
+	}
+}
+
+func (r *resolver) exprs(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		r.expr(expr)
+	}
+}
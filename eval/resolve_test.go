@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	input := `let a = 1;
+let f = fn(x, y) {
+	let z = x;
+	if (y) { let w = z; w + a } else { x = f }
+};`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	resolve(program)
+
+	type location struct {
+		depth, slot int
+	}
+	expected := map[string][]location{
+		"a": {{0, -1}, {2, -1}},
+		"f": {{0, -1}, {2, -1}},
+		"x": {{0, 0}, {0, 0}, {1, 0}},
+		"y": {{0, 1}, {0, 1}},
+		"z": {{0, 2}, {1, 2}},
+		"w": {{0, 0}, {0, 0}},
+	}
+	found := make(map[string][]location)
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			if !ident.Resolved {
+				t.Errorf("%s at %s not resolved", ident.Value, ident.Token.Pos)
+			}
+			found[ident.Value] = append(found[ident.Value], location{ident.Depth, ident.Slot})
+		}
+		return true
+	})
+
+	for name, locations := range expected {
+		if len(found[name]) != len(locations) {
+			t.Errorf("%s resolved to %v, expected=%v", name, found[name], locations)
+			continue
+		}
+		for i, loc := range locations {
+			if found[name][i] != loc {
+				t.Errorf("%s resolved to %v, expected=%v", name, found[name], locations)
+				break
+			}
+		}
+	}
+
+	fn := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if names := fn.Body.Names; len(names) != 3 || names[0] != "x" || names[1] != "y" || names[2] != "z" {
+		t.Errorf("wrong names for the function scope, got=%v", names)
+	}
+}
+
+func TestResolvedScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		// functions see declarations further down once they are reached
+		{"let f = fn() { let g = fn() { h() }; let h = fn() { 2 }; g() }; f()", 2},
+		{"let f = fn() { let g = fn() { h() }; g() }; let h = fn() { 3 }; f()", 3},
+		// a name used before its local declaration refers to the outer one
+		{"let x = 1; let f = fn() { let y = x; let x = 2; y * 10 + x }; f()", 12},
+		{"let f = fn() { let y = x; let x = 2; y }; f()", "identifier not found: x"},
+		{"let f = fn(x) { if (true) { let x = x + 1; x } }; f(1)", 2},
+		{"let counter = fn() { let n = 0; fn() { n = n + 1; n } }; let c = counter(); c(); c()", 2},
+		{"let f = fn() { const n = 1; fn() { n = 2 } }; f()()", "cannot assign to constant: n"},
+		{"let f = fn() { let n = 1; let n = 2; n }; f()", 2},
+		{"let f = fn() { try { throw(5) } catch (e) { let g = fn() { e }; g() } }; f()", 5},
+		{"let f = fn() { len }; f()", "identifier not found: len"},
+		{"let f = fn() { puts }; let puts = 1; f()", 1},
+		// parameters and declarations are bound to their slots
+		{"let f = fn(x, x) { x }; f(1, 2)", 2},
+		{"let f = fn(x, y) { let z = x - y; z }; f(5, 2)", 3},
+		{"let f = fn() { const n = 1; let n = 2 }; f()", "cannot redeclare constant: n"},
+		{"let f = fn() { import \"math\"; let g = fn() { math.abs(-4) }; g() }; f()", 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerOrError(t, testEval(tt.input), tt.expected)
+	}
+
+	// variables bound to values without statements are bound all the same
+	nulls := []string{
+		"let e = fn() {}; let a = 7; let f = fn() { let a = e(); a }; f()",
+		"let e = fn() {}; let f = fn() { let a = e(); a }; f()",
+		"let a = 7; let f = fn() { let a = if (true) {}; a }; f()",
+		"let a = 7; let f = fn() { let a = 1; a = if (true) {}; a }; f()",
+		"let f = fn(x) { x }; f(if (true) {})",
+	}
+	for _, input := range nulls {
+		testNullObject(t, testEval(input))
+	}
+
+	env := object.NewEnvironment()
+	env.OnRedeclare(object.ForbidRedeclare)
+	program := parser.New(lexer.New("let f = fn() { let n = 1; let n = 2; n }; f()")).ParseProgram()
+	testIntegerOrError(t, Eval(program, env), "identifier already declared: n")
+}
@@ -0,0 +1,138 @@
+// Package monkey embeds the Monkey interpreter in Go programs.
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimize"
+	"monkey/parser"
+	"os"
+)
+
+// DefaultMaxDepth is the number of nested function calls an Interpreter
+// allows unless WithLimits sets another, see eval.Limits.
+const DefaultMaxDepth = eval.DefaultMaxDepth
+
+// Interpreter evaluates Monkey source against a global environment that
+// persists between calls.
+type Interpreter struct {
+	env      *object.Environment
+	stdout   io.Writer
+	builtins map[string]*object.Builtin
+	imports  map[string]*object.Module
+	limits   eval.Limits
+	modules  *eval.Modules
+	optimize bool
+
+	peakMemory int64
+}
+
+func New(opts ...Option) *Interpreter {
+	i := &Interpreter{
+		env:      object.NewEnvironment(),
+		stdout:   os.Stdout,
+		builtins: map[string]*object.Builtin{},
+		imports:  map[string]*object.Module{},
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if _, ok := i.builtins["puts"]; !ok {
+		i.builtins["puts"] = eval.Puts(i.stdout)
+	}
+	return i
+}
+
+// Eval parses and evaluates src, returning the value of its last statement.
+func (i *Interpreter) Eval(src string) (object.Object, error) {
+	return i.EvalContext(context.Background(), src)
+}
+
+// EvalContext is like Eval but stops once ctx is done.
+func (i *Interpreter) EvalContext(ctx context.Context, src string) (object.Object, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return nil, &ParseError{Errors: errors}
+	}
+	if i.optimize {
+		optimize.Optimize(program)
+	}
+
+	evaluator := i.evaluator(ctx)
+	result := evaluator.Eval(program, i.env)
+	i.peakMemory = evaluator.PeakMemory()
+
+	return unwrap(result)
+}
+
+// EvalFile evaluates the Monkey source in the file at path.
+func (i *Interpreter) EvalFile(path string) (object.Object, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := i.Eval(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
+
+// Set binds a global variable visible to subsequently evaluated code.
+func (i *Interpreter) Set(name string, val object.Object) {
+	i.env.Set(name, val)
+}
+
+// Get looks up a global variable.
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.env.Get(name)
+}
+
+// Call invokes the global function fnName with args.
+func (i *Interpreter) Call(fnName string, args ...object.Object) (object.Object, error) {
+	fn, ok := i.env.Get(fnName)
+	if !ok {
+		fn, ok = i.builtins[fnName]
+	}
+	if !ok {
+		return nil, fmt.Errorf("function not found: %s", fnName)
+	}
+
+	evaluator := i.evaluator(context.Background())
+	result := evaluator.Apply(fn, args...)
+	i.peakMemory = evaluator.PeakMemory()
+
+	return unwrap(result)
+}
+
+// PeakMemory reports the memory accounted to the last Eval or Call, see
+// eval.Limits.MaxMemory.
+func (i *Interpreter) PeakMemory() int64 {
+	return i.peakMemory
+}
+
+func (i *Interpreter) evaluator(ctx context.Context) *eval.Evaluator {
+	evaluator := eval.New(ctx, i.limits)
+	evaluator.Builtins = i.builtins
+	evaluator.Imports = i.imports
+	evaluator.Modules = i.modules
+	return evaluator
+}
+
+func unwrap(result object.Object) (object.Object, error) {
+	if err, ok := result.(*object.Error); ok {
+		return nil, &RuntimeError{Err: err}
+	}
+	if result == nil {
+		return object.NULL, nil
+	}
+	return result, nil
+}
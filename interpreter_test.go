@@ -0,0 +1,307 @@
+package monkey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"monkey/eval"
+	"monkey/object"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.Eval("let add = fn(a, b) { a + b };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interp.Eval("add(2, 3)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 5)
+
+	result, err = interp.Eval("")
+	if err != nil || result != object.NULL {
+		t.Errorf("empty source should evaluate to null, got=%v (%v)", result, err)
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Eval("let = 5")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got=%T (%v)", err, err)
+	}
+	if len(parseErr.Errors) == 0 {
+		t.Errorf("parse error carries no messages")
+	}
+
+	_, err = interp.Eval("1 + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected a *RuntimeError, got=%T (%v)", err, err)
+	}
+	if err.Error() != "1:3: type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message, got=%q", err.Error())
+	}
+	if runtimeErr.Kind() != object.ERR_RUNTIME {
+		t.Errorf("wrong error kind, got=%s", runtimeErr.Kind())
+	}
+}
+
+func TestInterpreterGlobals(t *testing.T) {
+	interp := New()
+	interp.Set("answer", &object.Integer{Value: 42})
+
+	result, err := interp.Eval("let doubled = answer * 2; doubled")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 84)
+
+	doubled, ok := interp.Get("doubled")
+	if !ok {
+		t.Fatal("doubled should be a global")
+	}
+	testInteger(t, doubled, 84)
+
+	if _, ok := interp.Get("missing"); ok {
+		t.Error("missing should not be a global")
+	}
+}
+
+func TestInterpreterCall(t *testing.T) {
+	interp := New()
+	if _, err := interp.Eval("let mul = fn(a, b) { a * b }; let boom = fn() { throw(\"boom\") };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interp.Call("mul", &object.Integer{Value: 6}, &object.Integer{Value: 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 42)
+
+	if _, err := interp.Call("boom"); err == nil || err.Error() != "1:54: boom" {
+		t.Errorf("expected boom error, got=%v", err)
+	}
+
+	if _, err := interp.Call("missing"); err == nil || err.Error() != "function not found: missing" {
+		t.Errorf("expected function not found error, got=%v", err)
+	}
+}
+
+func TestInterpreterOptions(t *testing.T) {
+	var out bytes.Buffer
+
+	interp := New(
+		WithStdout(&out),
+		WithBuiltin("twice", func(args ...object.Object) object.Object {
+			n := args[0].(*object.Integer)
+			return &object.Integer{Value: n.Value * 2}
+		}),
+		WithLimits(eval.Limits{MaxDepth: 10}),
+	)
+
+	if _, err := interp.Eval(`puts("hello", twice(21))`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.String() != "hello\n42\n" {
+		t.Errorf("wrong output, got=%q", out.String())
+	}
+
+	_, err := interp.Eval("let f = fn() { 1 + f() }; f()")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Kind() != object.ERR_STACK_DEPTH {
+		t.Errorf("expected stack depth error, got=%v", err)
+	}
+}
+
+func TestInterpreterDefaultDepth(t *testing.T) {
+	deep := "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(%d)"
+
+	for _, interp := range []*Interpreter{New(), New(WithLimits(eval.Limits{MaxSteps: 1 << 30}))} {
+		result, err := interp.Eval(fmt.Sprintf(deep, DefaultMaxDepth-1))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		testInteger(t, result, DefaultMaxDepth-1)
+
+		_, err = interp.Eval(fmt.Sprintf(deep, 10*DefaultMaxDepth))
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Kind() != object.ERR_STACK_DEPTH {
+			t.Errorf("expected stack depth error, got=%v", err)
+		}
+	}
+}
+
+func TestInterpreterEvalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.mk")
+	if err := os.WriteFile(path, []byte("let x = 20;\nx + 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().EvalFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 21)
+
+	if err := os.WriteFile(path, []byte("x + 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New().EvalFile(path)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected a *RuntimeError, got=%T (%v)", err, err)
+	}
+	if err.Error() != path+": 1:1: identifier not found: x" {
+		t.Errorf("wrong error message, got=%q", err.Error())
+	}
+}
+
+func testInteger(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not an Integer, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object was expected to have value of %d, got=%d",
+			expected, result.Value)
+		return false
+	}
+	return true
+}
+
+func TestInterpreterGoValues(t *testing.T) {
+	type user struct {
+		Name string `monkey:"name"`
+		Age  int    `monkey:"age"`
+	}
+
+	interp := New()
+
+	users, err := object.FromGo([]user{{"ann", 31}, {"bob", 27}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp.Set("users", users)
+
+	greet, err := object.FromGo(func(u user) string { return "hi " + u.Name })
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp.Set("greet", greet)
+
+	result, err := interp.Eval(`[greet(users[1]), users[0]["age"] + 1]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []any
+	if err := object.ToGo(result, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "hi bob" || got[1] != int64(32) {
+		t.Errorf("wrong result, got=%#v", got)
+	}
+
+	if _, err := interp.Eval(`greet(1)`); err == nil ||
+		err.Error() != "1:6: argument 1: cannot convert INTEGER to monkey.user" {
+		t.Errorf("expected an argument error, got=%v", err)
+	}
+}
+
+func TestInterpreterModules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/util.mk": {Data: []byte(`let double = fn(x) { x * 2 };`)},
+	}
+	interp := New(WithModules(fsys))
+
+	if _, err := interp.Eval(`import "lib/util";`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := interp.Eval("util.double(21)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 42)
+
+	if _, err := New().Eval(`import "lib/util"`); err == nil {
+		t.Errorf("imports should fail without WithModules")
+	}
+}
+
+func TestInterpreterFS(t *testing.T) {
+	_, err := New().Eval(`import "fs"; fs.read("x")`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Kind() != object.ERR_PERMISSION {
+		t.Fatalf("file system access should be denied by default, got=%v", err)
+	}
+
+	dir := t.TempDir()
+	interp := New(WithRootDir(dir))
+	if _, err := interp.Eval(`import "fs"; fs.write("report.txt", "ok")`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil || string(data) != "ok" {
+		t.Errorf("report.txt not written, got=%q (%v)", data, err)
+	}
+}
+
+func TestInterpreterClock(t *testing.T) {
+	now := time.Date(2030, time.June, 1, 9, 0, 0, 0, time.UTC)
+	interp := New(WithClock(func() time.Time { return now }))
+
+	result, err := interp.Eval(`import "time"; time.format(time.now() + time.HOUR)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if str, ok := result.(*object.String); !ok || str.Value != "2030-06-01T10:00:00Z" {
+		t.Errorf("time.now should read the injected clock, got=%s", result.Inspect())
+	}
+}
+
+func TestInterpreterOptimization(t *testing.T) {
+	interp := New(WithOptimization())
+
+	result, err := interp.Eval("const size = 2 * 8; let f = fn(x) { x * size }; f(2)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, result, 32)
+
+	if _, err := interp.Eval("size / (4 - 4)"); err == nil || err.Error() != "1:6: division by zero" {
+		t.Errorf("expected division by zero, got=%v", err)
+	}
+}
+
+func TestInterpreterOptimizationMemory(t *testing.T) {
+	interp := New(WithOptimization(), WithLimits(eval.Limits{MaxMemory: 1 << 20}))
+
+	// folding would build a string of 10 GiB before evaluating anything
+	var src strings.Builder
+	src.WriteString(`const s0 = "aaaaaaaaaa";`)
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&src, " const s%d = s%d + s%d;", i, i-1, i-1)
+	}
+
+	_, err := interp.Eval(src.String())
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Kind() != object.ERR_MEMORY_LIMIT {
+		t.Errorf("expected memory limit error, got=%v", err)
+	}
+}
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"monkey/token"
+	"strings"
 )
 
 type Lexer struct {
@@ -9,18 +10,34 @@ type Lexer struct {
 	pos         int
 	readPos     int
 	currentChar byte
+
+	line   int
+	column int
+
+	comments []token.Token
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input, 0, 0, 0}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWhitespaces()
+	for l.currentChar == '/' && l.peek() == '/' {
+		l.readComment()
+		l.skipWhitespaces()
+	}
+
+	start := token.Position{Line: l.line, Column: l.column}
+	tok := l.nextToken()
+	tok.Pos = start
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
+	var tok token.Token
 
 	switch l.currentChar {
 	case '=':
@@ -41,6 +58,12 @@ func (l *Lexer) NextToken() token.Token {
 	case ';':
 		tok = newtoken(token.SEMICOLON, string(l.currentChar))
 
+	case ':':
+		tok = newtoken(token.COLON, string(l.currentChar))
+
+	case '.':
+		tok = newtoken(token.DOT, string(l.currentChar))
+
 	case '(':
 		tok = newtoken(token.LPAREN, string(l.currentChar))
 
@@ -53,6 +76,12 @@ func (l *Lexer) NextToken() token.Token {
 	case '}':
 		tok = newtoken(token.RCURLY, string(l.currentChar))
 
+	case '[':
+		tok = newtoken(token.LBRACKET, string(l.currentChar))
+
+	case ']':
+		tok = newtoken(token.RBRACKET, string(l.currentChar))
+
 	case '!':
 		nextChar := l.peek()
 		if nextChar == '=' {
@@ -77,6 +106,15 @@ func (l *Lexer) NextToken() token.Token {
 	case '>':
 		tok = newtoken(token.GT, string(l.currentChar))
 
+	case '"':
+		if str, ok := l.readString(); ok {
+			tok = newtoken(token.STRING, str)
+		} else {
+			// the parser reports the quote without its end, which leaves
+			// the lexer at the end of the input
+			return newtoken(token.ILLEGAL, `"`+str)
+		}
+
 	case 0:
 		tok = newtoken(token.EOF, "")
 
@@ -96,6 +134,10 @@ func (l *Lexer) NextToken() token.Token {
 			// if err != nil {
 			// 	panic(err)
 			// }
+			if l.currentChar == '.' && isInt(l.peek()) {
+				l.readChar()
+				return newtoken(token.FLOAT, num+"."+l.readInt())
+			}
 			return newtoken(token.INT, num)
 		}
 		tok = newtoken(token.ILLEGAL, string(l.currentChar))
@@ -105,6 +147,12 @@ func (l *Lexer) NextToken() token.Token {
 }
 
 func (l *Lexer) readChar() {
+	if l.currentChar == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
 	if l.readPos >= len(l.input) {
 		l.currentChar = 0
 	} else {
@@ -115,15 +163,35 @@ func (l *Lexer) readChar() {
 	l.readPos += 1
 }
 
+// Comments returns the comments read so far. Comments run from // to the
+// end of the line and are not returned as tokens, the literal of the
+// COMMENT tokens holds their text after the //.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+func (l *Lexer) readComment() {
+	start := token.Position{Line: l.line, Column: l.column}
+	l.readChar()
+	l.readChar()
+
+	pos := l.pos
+	for l.currentChar != '\n' && l.currentChar != 0 {
+		l.readChar()
+	}
+	l.comments = append(l.comments, token.Token{Type: token.COMMENT, Literal: l.input[pos:l.pos], Pos: start})
+}
+
 func (l *Lexer) skipWhitespaces() {
 	for isWhitespace(l.currentChar) {
 		l.readChar()
 	}
 }
 
+// readIdent reads a letter followed by letters and digits.
 func (l *Lexer) readIdent() string {
 	pos := l.pos
-	for isLetter(l.currentChar) {
+	for isLetter(l.currentChar) || isInt(l.currentChar) {
 		l.readChar()
 	}
 	return l.input[pos:l.pos]
@@ -142,6 +210,37 @@ func (l *Lexer) readInt() string {
 	// return n, nil
 }
 
+// readString reads a double-quoted string starting at the opening quote and
+// leaves the lexer on the closing one. \n, \t, \" and \\ are unescaped.
+// It reports whether the string was closed before the input ended.
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+
+	for {
+		l.readChar()
+		switch l.currentChar {
+		case '"':
+			return out.String(), true
+		case 0:
+			return out.String(), false
+		case '\\':
+			l.readChar()
+			switch l.currentChar {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 0:
+				return out.String(), false
+			default:
+				out.WriteByte(l.currentChar)
+			}
+		default:
+			out.WriteByte(l.currentChar)
+		}
+	}
+}
+
 func (l *Lexer) peek() byte {
 	if l.readPos >= len(l.input) {
 		return 0
@@ -153,6 +252,8 @@ func identOrKeyword(ident string) token.TokenType {
 	switch ident {
 	case "let":
 		return token.LET
+	case "const":
+		return token.CONST
 	case "fn":
 		return token.FUNCTION
 	case "if":
@@ -161,6 +262,14 @@ func identOrKeyword(ident string) token.TokenType {
 		return token.ELSE
 	case "return":
 		return token.RETURN
+	case "try":
+		return token.TRY
+	case "catch":
+		return token.CATCH
+	case "finally":
+		return token.FINALLY
+	case "import":
+		return token.IMPORT
 	case "true":
 		return token.TRUE
 	case "false":
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"monkey/token"
@@ -26,6 +28,17 @@ if (5 < 10) {
 
 10 == 10;
 10 != 9;
+const limit = 10;
+limit = 5;
+"foobar"
+"foo bar"
+"say \"hi\"\n"
+try { 1 } catch (e) { 2 } finally { 3 }
+[1, 2.5];
+{"foo": 0.25}
+user.greet(1.5)
+import "lib" as l
+atan2 x_1 2x
 `
 
 	tests := []struct {
@@ -104,6 +117,59 @@ if (5 < 10) {
 		{token.NOT_EQUALS, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.CONST, "const"},
+		{token.IDENTIFIER, "limit"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "limit"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.STRING, "say \"hi\"\n"},
+		{token.TRY, "try"},
+		{token.LCURLY, "{"},
+		{token.INT, "1"},
+		{token.RCURLY, "}"},
+		{token.CATCH, "catch"},
+		{token.LPAREN, "("},
+		{token.IDENTIFIER, "e"},
+		{token.RPAREN, ")"},
+		{token.LCURLY, "{"},
+		{token.INT, "2"},
+		{token.RCURLY, "}"},
+		{token.FINALLY, "finally"},
+		{token.LCURLY, "{"},
+		{token.INT, "3"},
+		{token.RCURLY, "}"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.FLOAT, "2.5"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LCURLY, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.FLOAT, "0.25"},
+		{token.RCURLY, "}"},
+		{token.IDENTIFIER, "user"},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "greet"},
+		{token.LPAREN, "("},
+		{token.FLOAT, "1.5"},
+		{token.RPAREN, ")"},
+		{token.IMPORT, "import"},
+		{token.STRING, "lib"},
+		{token.IDENTIFIER, "as"},
+		{token.IDENTIFIER, "l"},
+		{token.IDENTIFIER, "atan2"},
+		{token.IDENTIFIER, "x_1"},
+		{token.INT, "2"},
+		{token.IDENTIFIER, "x"},
+		{token.EOF, ""},
 	}
 
 	l := New(input)
@@ -122,3 +188,107 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x + "a b";
+`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"+", 2, 5},
+		{"a b", 2, 7},
+		{";", 2, 12},
+		{"", 3, 1},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests [%d] failed, expected literal: %s, but got: %s",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests [%d] failed, expected position: %d:%d, but got: %s",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := New(`x = "abc`)
+
+	expected := []token.Token{
+		{Type: token.IDENTIFIER, Literal: "x", Pos: token.Position{Line: 1, Column: 1}},
+		{Type: token.ASSIGN, Literal: "=", Pos: token.Position{Line: 1, Column: 3}},
+		{Type: token.ILLEGAL, Literal: `"abc`, Pos: token.Position{Line: 1, Column: 5}},
+		{Type: token.EOF, Literal: "", Pos: token.Position{Line: 1, Column: 9}},
+	}
+	for i, want := range expected {
+		if tok := l.NextToken(); tok != want {
+			t.Fatalf("tests[%d]: wrong token, expected=%v, got=%v", i, want, tok)
+		}
+	}
+}
+
+func TestIdentifierDigits(t *testing.T) {
+	l := New(`math.atan2(y1, 2x)`)
+
+	expected := []token.Token{
+		{Type: token.IDENTIFIER, Literal: "math"},
+		{Type: token.DOT, Literal: "."},
+		{Type: token.IDENTIFIER, Literal: "atan2"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENTIFIER, Literal: "y1"},
+		{Type: token.COMMA, Literal: ","},
+		// identifiers still start with a letter
+		{Type: token.INT, Literal: "2"},
+		{Type: token.IDENTIFIER, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	for i, want := range expected {
+		tok := l.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d]: wrong token, expected=%v, got=%v", i, want, tok)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 5; // trailing
+x / 2 //`
+
+	l := New(input)
+
+	var literals []string
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		literals = append(literals, tok.Literal)
+	}
+	if got := strings.Join(literals, " "); got != "let x = 5 ; x / 2" {
+		t.Fatalf("comments should be skipped, got=%q", got)
+	}
+
+	expected := []token.Token{
+		{Type: token.COMMENT, Literal: " leading", Pos: token.Position{Line: 1, Column: 1}},
+		{Type: token.COMMENT, Literal: " trailing", Pos: token.Position{Line: 2, Column: 12}},
+		{Type: token.COMMENT, Literal: "", Pos: token.Position{Line: 3, Column: 7}},
+	}
+	if !slices.Equal(l.Comments(), expected) {
+		t.Errorf("wrong comments, got=%v", l.Comments())
+	}
+}
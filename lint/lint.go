@@ -0,0 +1,184 @@
+// Package lint enforces conventions on programs that are valid but likely
+// mistaken or hard to read, such as code after a return or comparisons
+// with true. Each rule can be turned off in a Config, and issues can be
+// suppressed with comments:
+//
+//	x == true // lint:ignore bool-compare
+//
+// suppresses the named rules on the line of the comment and the next one,
+// and
+//
+//	// lint:file-ignore naming, deep-nesting
+//
+// suppresses them in the whole file.
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Issue is a violation of the rule named Rule.
+type Issue struct {
+	Pos  token.Position
+	Rule string
+	Msg  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Pos, i.Msg, i.Rule)
+}
+
+const (
+	NAMING_CAMEL_CASE = "camelCase"
+	NAMING_SNAKE_CASE = "snake_case"
+)
+
+// Config selects the rules to run and their options.
+type Config struct {
+	// Rules enables or disables rules by name, the rules not listed are
+	// enabled
+	Rules map[string]bool `json:"rules"`
+	// MaxNesting is the depth of nested blocks deep-nesting allows
+	MaxNesting int `json:"max-nesting"`
+	// Naming is the convention the naming rule enforces, camelCase or
+	// snake_case
+	Naming string `json:"naming"`
+}
+
+func DefaultConfig() Config {
+	return Config{MaxNesting: 4, Naming: NAMING_CAMEL_CASE}
+}
+
+// LoadConfig reads a JSON config file. The options it leaves out keep
+// their default.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c Config) validate() error {
+	for name := range c.Rules {
+		if !slices.ContainsFunc(rules, func(r rule) bool { return r.name == name }) {
+			return fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	if c.MaxNesting < 1 {
+		return fmt.Errorf("max-nesting must be positive, got %d", c.MaxNesting)
+	}
+	if c.Naming != NAMING_CAMEL_CASE && c.Naming != NAMING_SNAKE_CASE {
+		return fmt.Errorf("naming must be %s or %s, got %q", NAMING_CAMEL_CASE, NAMING_SNAKE_CASE, c.Naming)
+	}
+	return nil
+}
+
+func (c Config) enabled(name string) bool {
+	enabled, ok := c.Rules[name]
+	return !ok || enabled
+}
+
+// RuleNames lists the names of the rules in the order they run.
+func RuleNames() []string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.name
+	}
+	return names
+}
+
+// Lint runs the rules config enables on program and returns the issues
+// found, ordered by position, leaving out those suppressed by comments.
+func Lint(program *ast.Program, comments []token.Token, config Config) []Issue {
+	p := &pass{config: config}
+	for _, r := range rules {
+		if config.enabled(r.name) {
+			p.rule = r.name
+			r.check(p, program)
+		}
+	}
+
+	s := suppressions(comments)
+	issues := slices.DeleteFunc(p.issues, s.suppressed)
+	slices.SortStableFunc(issues, func(a, b Issue) int {
+		switch {
+		case a.Pos.Before(b.Pos):
+			return -1
+		case b.Pos.Before(a.Pos):
+			return 1
+		default:
+			return 0
+		}
+	})
+	return issues
+}
+
+type pass struct {
+	config Config
+	rule   string
+	issues []Issue
+}
+
+func (p *pass) report(pos token.Position, format string, args ...any) {
+	p.issues = append(p.issues, Issue{Pos: pos, Rule: p.rule, Msg: fmt.Sprintf(format, args...)})
+}
+
+type suppression struct {
+	// lines maps line numbers to the rules suppressed on them
+	lines map[int][]string
+	file  []string
+}
+
+const (
+	ignoreDirective     = "lint:ignore"
+	fileIgnoreDirective = "lint:file-ignore"
+)
+
+func suppressions(comments []token.Token) *suppression {
+	s := &suppression{lines: make(map[int][]string)}
+
+	for _, comment := range comments {
+		text := strings.TrimSpace(comment.Literal)
+		if names, ok := strings.CutPrefix(text, fileIgnoreDirective); ok {
+			s.file = append(s.file, ruleList(names)...)
+		} else if names, ok := strings.CutPrefix(text, ignoreDirective); ok {
+			line := comment.Pos.Line
+			s.lines[line] = append(s.lines[line], ruleList(names)...)
+			s.lines[line+1] = append(s.lines[line+1], ruleList(names)...)
+		}
+	}
+	return s
+}
+
+func ruleList(names string) []string {
+	var list []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+func (s *suppression) suppressed(issue Issue) bool {
+	return slices.Contains(s.file, issue.Rule) || slices.Contains(s.lines[issue.Pos.Line], issue.Rule)
+}
@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func testLint(t *testing.T, input string, config Config) []string {
+	t.Helper()
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	issues := Lint(program, l.Comments(), config)
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.String()
+	}
+	return out
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`let f = fn(x) { return x; puts(x); x }`,
+			[]string{"1:27: unreachable code after return at 1:17 (unreachable)"}},
+		{`let f = fn(x) { if (x) { return 1 } 2 }`, nil},
+		{`if (true) { 1 }`, []string{"1:1: condition true is constant (constant-condition)"}},
+		{`if (1 < 2) { 1 }`, []string{"1:1: condition (1 < 2) is constant (constant-condition)"}},
+		{`let x = 1; if (x < 2) { 1 }`, nil},
+		{`let x = true; x == true`, []string{"1:17: comparison with true, simplify to x (bool-compare)"}},
+		{`let x = true; false != x`, []string{"1:21: comparison with false, simplify to x (bool-compare)"}},
+		{`let x = true; x == false`, []string{"1:17: comparison with false, simplify to !x (bool-compare)"}},
+		{`let x = 1; x = x`, []string{"1:14: self-assignment of x (self-assign)"}},
+		{`let p = point(); p.x = p.x`, []string{"1:22: self-assignment of p.x (self-assign)"}},
+		{`let p = point(); p.x = p.y`, nil},
+		{`let x = 1; if (x > 0) { } else { 2 }`, []string{"1:23: empty if block (empty-block)"}},
+		{`try { 1 } catch (e) { }`, []string{"1:21: empty catch block (empty-block)"}},
+		{`let noop = fn() { }`, nil},
+		{`let f = fn(x) { if (x) { if (x) { if (x) { if (x) { 1 } } } } }`,
+			[]string{"1:51: block nested 5 deep, more than 4 (deep-nesting)"}},
+		{`let my_var = 1; const MAX_SIZE = 2; let f = fn(_, firstName) { firstName }`,
+			[]string{"1:5: name my_var is not in camelCase (naming)"}},
+		{`try { 1 } catch (Err) { 2 }`, []string{"1:18: name Err is not in camelCase (naming)"}},
+	}
+
+	for _, tt := range tests {
+		got := testLint(t, tt.input, DefaultConfig())
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("wrong issues for %q\nwant=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	input := `let my_var = 1;
+if (my_var == true) { if (true) { } }`
+
+	config := DefaultConfig()
+	config.Rules = map[string]bool{"empty-block": false, "constant-condition": false, "bool-compare": false}
+	config.MaxNesting = 1
+	config.Naming = NAMING_SNAKE_CASE
+
+	expected := []string{"2:33: block nested 2 deep, more than 1 (deep-nesting)"}
+	if got := testLint(t, input, config); !slices.Equal(got, expected) {
+		t.Errorf("wrong issues\nwant=%q\ngot=%q", expected, got)
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	input := `// lint:file-ignore naming
+let my_var = true;
+// lint:ignore bool-compare, self-assign
+my_var == true;
+my_var == true; // lint:ignore bool-compare
+my_var = my_var;
+my_var == false; // lint:ignore self-assign
+`
+
+	expected := []string{
+		"6:8: self-assignment of my_var (self-assign)",
+		"7:8: comparison with false, simplify to !my_var (bool-compare)",
+	}
+	if got := testLint(t, input, DefaultConfig()); !slices.Equal(got, expected) {
+		t.Errorf("wrong issues\nwant=%q\ngot=%q", expected, got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "lint.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config, err := LoadConfig(write(`{"rules": {"naming": false}, "max-nesting": 2}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.enabled("naming") || !config.enabled("unreachable") || config.MaxNesting != 2 ||
+		config.Naming != NAMING_CAMEL_CASE {
+		t.Errorf("wrong config, got=%+v", config)
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{`{"rules": {"nope": true}}`, `unknown rule "nope"`},
+		{`{"naming": "kebab"}`, `naming must be camelCase or snake_case, got "kebab"`},
+		{`{"max-nesting": 0}`, "max-nesting must be positive, got 0"},
+		{`{"colour": true}`, `json: unknown field "colour"`},
+	}
+	for _, tt := range tests {
+		path := write(tt.content)
+		if _, err := LoadConfig(path); err == nil || err.Error() != path+": "+tt.expected {
+			t.Errorf("wrong error for %s, got=%v", tt.content, err)
+		}
+	}
+}
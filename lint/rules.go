@@ -0,0 +1,224 @@
+package lint
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"regexp"
+)
+
+type rule struct {
+	name  string
+	check func(p *pass, program *ast.Program)
+}
+
+var rules = []rule{
+	{"unreachable", unreachable},
+	{"constant-condition", constantCondition},
+	{"bool-compare", boolCompare},
+	{"self-assign", selfAssign},
+	{"empty-block", emptyBlock},
+	{"deep-nesting", deepNesting},
+	{"naming", naming},
+}
+
+// unreachable reports the first statement after a return in a block.
+func unreachable(p *pass, program *ast.Program) {
+	check := func(stmts []ast.Statement) {
+		for i, stmt := range stmts[:max(len(stmts)-1, 0)] {
+			if ret, ok := stmt.(*ast.ReturnStatement); ok {
+				p.report(stmtPos(stmts[i+1]), "unreachable code after return at %s", ret.Token.Pos)
+				return
+			}
+		}
+	}
+
+	check(program.Statements)
+	ast.Inspect(program, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockStatement); ok {
+			check(block.Statements)
+		}
+		return true
+	})
+}
+
+func stmtPos(stmt ast.Statement) token.Position {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Pos
+	case *ast.ImportStatement:
+		return stmt.Token.Pos
+	case *ast.ReturnStatement:
+		return stmt.Token.Pos
+	case *ast.ExpressionStatement:
+		return stmt.Token.Pos
+	case *ast.BlockStatement:
+		return stmt.Token.Pos
+	default:
+		return token.Position{}
+	}
+}
+
+// constantCondition reports if conditions made of literals only.
+func constantCondition(p *pass, program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ifExp, ok := node.(*ast.IfExpression); ok && isConstant(ifExp.Condition) {
+			p.report(ifExp.Token.Pos, "condition %s is constant", ifExp.Condition)
+		}
+		return true
+	})
+}
+
+func isConstant(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.Boolean, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(expr.Right)
+	case *ast.InfixExpression:
+		return isConstant(expr.Left) && isConstant(expr.Right)
+	default:
+		return false
+	}
+}
+
+// boolCompare reports comparisons with true and false, which can be
+// written as the operand itself or its negation.
+func boolCompare(p *pass, program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		infix, ok := node.(*ast.InfixExpression)
+		if !ok || infix.Operator != "==" && infix.Operator != "!=" {
+			return true
+		}
+
+		literal, operand := infix.Right, infix.Left
+		if _, ok := infix.Left.(*ast.Boolean); ok {
+			literal, operand = infix.Left, infix.Right
+		}
+		b, ok := literal.(*ast.Boolean)
+		if !ok {
+			return true
+		}
+
+		simpler := operand.String()
+		if b.Value != (infix.Operator == "==") {
+			simpler = "!" + simpler
+		}
+		p.report(infix.Token.Pos, "comparison with %s, simplify to %s", b, simpler)
+		return true
+	})
+}
+
+// selfAssign reports assignments of a variable, element or member to
+// itself.
+func selfAssign(p *pass, program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignExpression)
+		if ok && isLocation(assign.Target) && assign.Target.String() == assign.Value.String() {
+			p.report(assign.Token.Pos, "self-assignment of %s", assign.Target)
+		}
+		return true
+	})
+}
+
+// isLocation reports whether expr refers to a variable, element or member
+// without calling a function, so that evaluating it twice gives the same
+// result.
+func isLocation(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.IndexExpression:
+		return isLocation(expr.Left) && (isLocation(expr.Index) || isConstant(expr.Index))
+	case *ast.DotExpression:
+		return isLocation(expr.Left)
+	default:
+		return false
+	}
+}
+
+// emptyBlock reports the empty blocks of if and try expressions. Empty
+// function bodies are allowed as callbacks that do nothing.
+func emptyBlock(p *pass, program *ast.Program) {
+	check := func(block *ast.BlockStatement, what string) {
+		if block != nil && len(block.Statements) == 0 {
+			p.report(block.Token.Pos, "empty %s block", what)
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfExpression:
+			check(node.Consequence, "if")
+			check(node.Alternative, "else")
+		case *ast.TryExpression:
+			check(node.Block, "try")
+			check(node.Catch, "catch")
+			check(node.Finally, "finally")
+		}
+		return true
+	})
+}
+
+// deepNesting reports the blocks nested deeper than the configured
+// maximum, once for each outermost such block.
+func deepNesting(p *pass, program *ast.Program) {
+	var walk func(node ast.Node, depth int)
+	walk = func(node ast.Node, depth int) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			block, ok := n.(*ast.BlockStatement)
+			if !ok || n == node {
+				return true
+			}
+			if depth+1 > p.config.MaxNesting {
+				p.report(block.Token.Pos, "block nested %d deep, more than %d", depth+1, p.config.MaxNesting)
+			} else {
+				walk(block, depth+1)
+			}
+			return false
+		})
+	}
+	walk(program, 0)
+}
+
+var (
+	camelCase = regexp.MustCompile(`^_*[a-z][a-zA-Z0-9]*$`)
+	snakeCase = regexp.MustCompile(`^_*[a-z][a-z0-9_]*$`)
+	// constants may also be written in upper case, like the constants of
+	// the standard library
+	upperCase   = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	underscores = regexp.MustCompile(`^_+$`)
+)
+
+// naming reports the variables, constants and parameters whose name does
+// not follow the configured convention. Names made of underscores only
+// are allowed.
+func naming(p *pass, program *ast.Program) {
+	style := camelCase
+	if p.config.Naming == NAMING_SNAKE_CASE {
+		style = snakeCase
+	}
+
+	check := func(ident *ast.Identifier, constant bool) {
+		name := ident.Value
+		if style.MatchString(name) || constant && upperCase.MatchString(name) || underscores.MatchString(name) {
+			return
+		}
+		p.report(ident.Token.Pos, "name %s is not in %s", name, p.config.Naming)
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			check(node.Name, node.IsConst())
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				check(param, false)
+			}
+		case *ast.TryExpression:
+			if node.Param != nil {
+				check(node.Param, false)
+			}
+		}
+		return true
+	})
+}
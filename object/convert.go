@@ -0,0 +1,551 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// FromGo converts a Go value to its Monkey equivalent. Integers, floats,
+// strings and booleans map to the matching scalar objects, slices and
+// arrays to arrays, maps and structs to hashes, nil to null and funcs to
+// builtins that validate and convert their arguments. Struct fields are
+// keyed by name unless renamed with a `monkey:"name"` tag, `monkey:"-"`
+// skips a field. Values that already are objects are returned as they are.
+func FromGo(v any) (Object, error) {
+	return fromGo(reflect.ValueOf(v), map[uintptr]bool{})
+}
+
+// ToGo stores obj in the value target points to, converting it to the
+// target's type. Converting to an empty interface produces int64, float64,
+// string, bool, nil, []any and map[string]any (map[any]any if a hash has
+// non-string keys).
+func ToGo(obj Object, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("target must be a non-nil pointer")
+	}
+
+	val, err := toGo(obj, rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(val)
+	return nil
+}
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// fromGo converts v, seen holds the references on the current path so
+// that cyclic data is reported instead of recursing forever.
+func fromGo(v reflect.Value, seen map[uintptr]bool) (Object, error) {
+	if !v.IsValid() {
+		return NULL, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return NULL, nil
+		}
+	}
+
+	if v.Type().Implements(objectType) {
+		return v.Interface().(Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return AsBool(v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return AsInt(v.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows INTEGER", v.Uint())
+		}
+		return AsInt(int64(v.Uint())), nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		return fromGoSlice(v, seen)
+
+	case reflect.Map:
+		return fromGoMap(v, seen)
+
+	case reflect.Struct:
+		return fromGoStruct(v, seen)
+
+	case reflect.Pointer:
+		ptr := v.Pointer()
+		if seen[ptr] {
+			return nil, fmt.Errorf("cannot convert cyclic %s", v.Type())
+		}
+		seen[ptr] = true
+		defer delete(seen, ptr)
+		return fromGo(v.Elem(), seen)
+
+	case reflect.Interface:
+		return fromGo(v.Elem(), seen)
+
+	case reflect.Func:
+		return builtinFromGo(v)
+
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a Monkey value", v.Type())
+	}
+}
+
+func fromGoSlice(v reflect.Value, seen map[uintptr]bool) (Object, error) {
+	if v.Kind() == reflect.Slice && v.Len() > 0 {
+		ptr := v.Pointer()
+		if seen[ptr] {
+			return nil, fmt.Errorf("cannot convert cyclic %s", v.Type())
+		}
+		seen[ptr] = true
+		defer delete(seen, ptr)
+	}
+
+	elements := make([]Object, v.Len())
+	for i := range elements {
+		el, err := fromGo(v.Index(i), seen)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		elements[i] = el
+	}
+	return &Array{Elements: elements}, nil
+}
+
+func fromGoMap(v reflect.Value, seen map[uintptr]bool) (Object, error) {
+	ptr := v.Pointer()
+	if seen[ptr] {
+		return nil, fmt.Errorf("cannot convert cyclic %s", v.Type())
+	}
+	seen[ptr] = true
+	defer delete(seen, ptr)
+
+	pairs := make([]HashPair, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := fromGo(iter.Key(), seen)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.(Hashable); !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+
+		val, err := fromGo(iter.Value(), seen)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Inspect(), err)
+		}
+		pairs = append(pairs, HashPair{Key: key, Value: val})
+	}
+
+	// Go maps are unordered, sort so that the hash inspects the same way
+	// on every run
+	sort.Slice(pairs, func(i, j int) bool {
+		ki, kj := pairs[i].Key.(Hashable).HashKey(), pairs[j].Key.(Hashable).HashKey()
+		if ki.Type != kj.Type {
+			return ki.Type < kj.Type
+		}
+		return ki.Value < kj.Value
+	})
+
+	hash := NewHash()
+	for _, pair := range pairs {
+		hash.Set(pair.Key.(Hashable), pair.Value)
+	}
+	return hash, nil
+}
+
+func fromGoStruct(v reflect.Value, seen map[uintptr]bool) (Object, error) {
+	hash := NewHash()
+
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		val, err := fromGo(v.Field(i), seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		hash.Set(&String{Value: name}, val)
+	}
+	return hash, nil
+}
+
+// fieldName is the hash key a struct field is exposed as, if it is.
+func fieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+
+	tag := f.Tag.Get("monkey")
+	switch tag {
+	case "-":
+		return "", false
+	case "":
+		return f.Name, true
+	default:
+		return tag, true
+	}
+}
+
+func builtinFromGo(fn reflect.Value) (Object, error) {
+	t := fn.Type()
+
+	switch {
+	case t.NumOut() > 2,
+		t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("cannot convert %s: want at most one result and an error", t)
+	}
+
+	return &Builtin{Name: funcName(fn), Fn: func(args ...Object) (result Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = FormatError("%v", r)
+			}
+		}()
+
+		in, err := goArgs(t, args)
+		if err != nil {
+			return err
+		}
+		return goResult(t, fn.Call(in))
+	}}, nil
+}
+
+func funcName(fn reflect.Value) string {
+	name := runtime.FuncForPC(fn.Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// goArgs checks and converts the arguments of a call to a Go func of
+// type t.
+func goArgs(t reflect.Type, args []Object) ([]reflect.Value, *Error) {
+	if t.IsVariadic() && len(args) < t.NumIn()-1 {
+		return nil, FormatError("wrong number of arguments: want at least %d, got=%d",
+			t.NumIn()-1, len(args))
+	}
+	if !t.IsVariadic() && len(args) != t.NumIn() {
+		return nil, FormatError("wrong number of arguments: want=%d, got=%d",
+			t.NumIn(), len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(i)
+		}
+
+		val, err := toGo(arg, paramType)
+		if err != nil {
+			return nil, FormatError("argument %d: %s", i+1, err)
+		}
+		in[i] = val
+	}
+	return in, nil
+}
+
+func goResult(t reflect.Type, out []reflect.Value) Object {
+	if t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return FormatError("%s", err.Interface())
+		}
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		return NULL
+	}
+
+	result, err := fromGo(out[0], map[uintptr]bool{})
+	if err != nil {
+		return FormatError("%s", err)
+	}
+	return result
+}
+
+func toGo(obj Object, t reflect.Type) (reflect.Value, error) {
+	if obj == nil {
+		obj = NULL
+	}
+	objType := reflect.TypeOf(obj)
+
+	if t.Kind() == reflect.Interface && t.NumMethod() > 0 {
+		if objType.Implements(t) {
+			return reflect.ValueOf(obj), nil
+		}
+		return reflect.Value{}, convertError(obj, t)
+	}
+
+	if t.Kind() != reflect.Interface && objType.AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+
+	if obj == NULL {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		native := toNative(obj)
+		if native == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(native), nil
+
+	case reflect.Bool:
+		if b, ok := obj.(*Boolean); ok {
+			return reflect.ValueOf(b.Value).Convert(t), nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*Integer); ok {
+			val := reflect.New(t).Elem()
+			if val.OverflowInt(i.Value) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			val.SetInt(i.Value)
+			return val, nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*Integer); ok {
+			val := reflect.New(t).Elem()
+			if i.Value < 0 || val.OverflowUint(uint64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			val.SetUint(uint64(i.Value))
+			return val, nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *Float:
+			return reflect.ValueOf(n.Value).Convert(t), nil
+		case *Integer:
+			return reflect.ValueOf(float64(n.Value)).Convert(t), nil
+		}
+
+	case reflect.String:
+		if s, ok := obj.(*String); ok {
+			return reflect.ValueOf(s.Value).Convert(t), nil
+		}
+
+	case reflect.Slice:
+		if a, ok := obj.(*Array); ok {
+			return toGoElements(a, reflect.MakeSlice(t, len(a.Elements), len(a.Elements)))
+		}
+
+	case reflect.Array:
+		if a, ok := obj.(*Array); ok {
+			if len(a.Elements) != t.Len() {
+				return reflect.Value{}, fmt.Errorf("cannot convert ARRAY of length %d to %s",
+					len(a.Elements), t)
+			}
+			return toGoElements(a, reflect.New(t).Elem())
+		}
+
+	case reflect.Map:
+		if h, ok := obj.(*Hash); ok {
+			return toGoMap(h, t)
+		}
+
+	case reflect.Struct:
+		if h, ok := obj.(*Hash); ok {
+			return toGoStruct(h, t)
+		}
+
+	case reflect.Pointer:
+		val, err := toGo(obj, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(val)
+		return ptr, nil
+
+	case reflect.Func:
+		if b, ok := obj.(*Builtin); ok {
+			// without an error result the builtin's errors could only panic
+			if t.NumOut() == 0 || t.NumOut() > 2 || t.Out(t.NumOut()-1) != errorType {
+				return reflect.Value{}, fmt.Errorf("cannot convert %s to %s: want at most one result and an error", obj.Type(), t)
+			}
+			return goFunc(b, t), nil
+		}
+	}
+
+	return reflect.Value{}, convertError(obj, t)
+}
+
+func convertError(obj Object, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+}
+
+func toGoElements(a *Array, dst reflect.Value) (reflect.Value, error) {
+	for i, el := range a.Elements {
+		val, err := toGo(el, dst.Type().Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("index %d: %w", i, err)
+		}
+		dst.Index(i).Set(val)
+	}
+	return dst, nil
+}
+
+func toGoMap(h *Hash, t reflect.Type) (reflect.Value, error) {
+	m := reflect.MakeMapWithSize(t, h.Len())
+
+	for _, pair := range h.Pairs() {
+		key, err := toGo(pair.Key, t.Key())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		val, err := toGo(pair.Value, t.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+		}
+		m.SetMapIndex(key, val)
+	}
+	return m, nil
+}
+
+// toGoStruct fills the exposed fields of a new t from h. Fields without a
+// matching key keep their zero value.
+func toGoStruct(h *Hash, t reflect.Type) (reflect.Value, error) {
+	s := reflect.New(t).Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := fieldName(t.Field(i))
+		if !ok {
+			continue
+		}
+
+		el, ok := h.Get(&String{Value: name})
+		if !ok {
+			continue
+		}
+
+		val, err := toGo(el, t.Field(i).Type)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("field %s: %w", name, err)
+		}
+		s.Field(i).Set(val)
+	}
+	return s, nil
+}
+
+// goFunc wraps a builtin in a Go func of type t, whose last result must
+// be an error. Errors from the builtin are returned in it.
+func goFunc(b *Builtin, t reflect.Type) reflect.Value {
+	fail := func(err error) []reflect.Value {
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		return out
+	}
+
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]Object, len(in))
+		for i, v := range in {
+			arg, err := fromGo(v, map[uintptr]bool{})
+			if err != nil {
+				return fail(err)
+			}
+			args[i] = arg
+		}
+
+		result := b.Call(nil, args...)
+		if err, ok := result.(*Error); ok {
+			return fail(errors.New(err.Msg))
+		}
+
+		out := make([]reflect.Value, 0, t.NumOut())
+		if t.NumOut() == 2 {
+			val, err := toGo(result, t.Out(0))
+			if err != nil {
+				return fail(err)
+			}
+			out = append(out, val)
+		}
+		return append(out, reflect.Zero(errorType))
+	})
+}
+
+// toNative converts obj to the Go value it is closest to, objects without
+// a Go counterpart are returned as they are.
+func toNative(obj Object) any {
+	switch obj := obj.(type) {
+	case *Integer:
+		return obj.Value
+	case *Float:
+		return obj.Value
+	case *String:
+		return obj.Value
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return nil
+	case *Array:
+		elements := make([]any, len(obj.Elements))
+		for i, el := range obj.Elements {
+			elements[i] = toNative(el)
+		}
+		return elements
+	case *Hash:
+		return toNativeMap(obj)
+	default:
+		return obj
+	}
+}
+
+func toNativeMap(h *Hash) any {
+	stringKeys := true
+	for _, pair := range h.Pairs() {
+		if pair.Key.Type() != OBJ_STRING {
+			stringKeys = false
+			break
+		}
+	}
+
+	if stringKeys {
+		m := make(map[string]any, h.Len())
+		for _, pair := range h.Pairs() {
+			m[pair.Key.(*String).Value] = toNative(pair.Value)
+		}
+		return m
+	}
+
+	m := make(map[any]any, h.Len())
+	for _, pair := range h.Pairs() {
+		m[toNative(pair.Key)] = toNative(pair.Value)
+	}
+	return m
+}
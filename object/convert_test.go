@@ -0,0 +1,266 @@
+package object
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X      int
+	Y      int
+	Label  string `monkey:"label"`
+	Hidden bool   `monkey:"-"`
+	secret int
+}
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{5, "5"},
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{2.5, "2.5"},
+		{float32(1), "1.0"},
+		{"hello", "hello"},
+		{true, "true"},
+		{[]int{1, 2, 3}, "[1, 2, 3]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]any{1, "a", nil, false}, "[1, a, null, false]"},
+		{map[string]int{"b": 2, "a": 1}, "{a: 1, b: 2}"},
+		{map[int]bool{2: true, 1: false}, "{1: false, 2: true}"},
+		{point{X: 1, Y: 2, Label: "p", Hidden: true, secret: 3}, "{X: 1, Y: 2, label: p}"},
+		{&point{X: 1}, "{X: 1, Y: 0, label: }"},
+		{(*point)(nil), "null"},
+		{&Integer{Value: 9}, "9"},
+		{[]Object{TRUE, &String{Value: "x"}}, "[true, x]"},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.input)
+		if err != nil {
+			t.Errorf("FromGo(%#v) failed: %s", tt.input, err)
+			continue
+		}
+
+		if obj.Inspect() != tt.expected {
+			t.Errorf("FromGo(%#v) wrong, expected=%q, got=%q",
+				tt.input, tt.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	cyclic := &node{}
+	cyclic.Next = cyclic
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{uint64(1 << 63), "9223372036854775808 overflows INTEGER"},
+		{make(chan int), "cannot convert chan int to a Monkey value"},
+		{map[float64]int{1.5: 1}, "unusable as hash key: FLOAT"},
+		{cyclic, "cannot convert cyclic *object.node"},
+		{func() (int, int) { return 0, 0 }, "cannot convert func() (int, int): want at most one result and an error"},
+	}
+
+	for _, tt := range tests {
+		_, err := FromGo(tt.input)
+		if err == nil {
+			t.Errorf("FromGo(%T) should have failed", tt.input)
+			continue
+		}
+
+		if !strings.HasSuffix(err.Error(), tt.expected) {
+			t.Errorf("FromGo(%T) wrong error, expected=%q, got=%q",
+				tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestFromGoFunc(t *testing.T) {
+	add, err := FromGo(func(a, b int) int { return a + b })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builtin, ok := add.(*Builtin)
+	if !ok {
+		t.Fatalf("FromGo(func) should be a *Builtin, got=%T", add)
+	}
+
+	tests := []struct {
+		fn       *Builtin
+		args     []Object
+		expected string
+	}{
+		{builtin, []Object{AsInt(1), AsInt(2)}, "3"},
+		{builtin, []Object{AsInt(1)}, "ERROR: wrong number of arguments: want=2, got=1"},
+		{builtin, []Object{AsInt(1), &String{Value: "2"}}, "ERROR: argument 2: cannot convert STRING to int"},
+		{mustBuiltin(t, strings.Join), []Object{
+			&Array{Elements: []Object{&String{Value: "a"}, &String{Value: "b"}}},
+			&String{Value: "-"},
+		}, "a-b"},
+		{mustBuiltin(t, func(xs ...float64) float64 {
+			sum := 0.0
+			for _, x := range xs {
+				sum += x
+			}
+			return sum
+		}), []Object{AsInt(1), &Float{Value: 0.5}}, "1.5"},
+		{mustBuiltin(t, func(s string) (string, error) {
+			return "", errors.New("no " + s)
+		}), []Object{&String{Value: "way"}}, "ERROR: no way"},
+		{mustBuiltin(t, func(p point) int { return p.X + p.Y }), []Object{
+			mustFromGo(t, map[string]int{"X": 3, "Y": 4}),
+		}, "7"},
+		{mustBuiltin(t, func() { panic("oops") }), []Object{}, "ERROR: oops"},
+		{mustBuiltin(t, func(int8) {}), []Object{AsInt(300)}, "ERROR: argument 1: 300 overflows int8"},
+	}
+
+	for _, tt := range tests {
+		result := tt.fn.Fn(tt.args...)
+		if result.Inspect() != tt.expected {
+			t.Errorf("calling %s wrong, expected=%q, got=%q",
+				tt.fn.Name, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	var i int
+	testToGo(t, AsInt(42), &i, 42)
+
+	var u uint8
+	testToGo(t, AsInt(200), &u, uint8(200))
+
+	var f float64
+	testToGo(t, AsInt(2), &f, 2.0)
+
+	var s string
+	testToGo(t, &String{Value: "hi"}, &s, "hi")
+
+	var b bool
+	testToGo(t, TRUE, &b, true)
+
+	var ints []int
+	testToGo(t, &Array{Elements: []Object{AsInt(1), AsInt(2)}}, &ints, []int{1, 2})
+
+	var pair [2]string
+	testToGo(t, &Array{Elements: []Object{&String{Value: "a"}, &String{Value: "b"}}},
+		&pair, [2]string{"a", "b"})
+
+	var m map[string]int
+	testToGo(t, mustFromGo(t, map[string]int{"a": 1}), &m, map[string]int{"a": 1})
+
+	var p point
+	testToGo(t, mustFromGo(t, map[string]any{"X": 1, "label": "l", "Hidden": true}), &p,
+		point{X: 1, Label: "l"})
+
+	var pp *point
+	testToGo(t, NULL, &pp, (*point)(nil))
+	testToGo(t, mustFromGo(t, map[string]int{"Y": 5}), &pp, &point{Y: 5})
+
+	var native any
+	testToGo(t, mustFromGo(t, map[string]any{"a": []any{1, 2.5, "x", nil}}), &native,
+		map[string]any{"a": []any{int64(1), 2.5, "x", nil}})
+	testToGo(t, mustFromGo(t, map[int]string{1: "a"}), &native, map[any]any{int64(1): "a"})
+
+	var obj Object
+	testToGo(t, AsInt(1), &obj, Object(AsInt(1)))
+
+	var fn func(int, int) (int, error)
+	add := mustFromGo(t, func(a, b int) int { return a + b })
+	testToGo(t, add, &fn, nil)
+	if n, err := fn(2, 3); n != 5 || err != nil {
+		t.Errorf("converted func returned %d, %v", n, err)
+	}
+	if _, err := mustBuiltinFunc(t, add)(1); err == nil {
+		t.Error("converted func should report the builtin's error")
+	}
+}
+
+func TestToGoErrors(t *testing.T) {
+	var i int8
+	var s string
+	var a [3]int
+	var fn func()
+	var noErr func(int) int
+	var twoResults func() (int, int)
+
+	tests := []struct {
+		obj      Object
+		target   any
+		expected string
+	}{
+		{AsInt(1), i, "target must be a non-nil pointer"},
+		{AsInt(1000), &i, "1000 overflows int8"},
+		{AsInt(1), &s, "cannot convert INTEGER to string"},
+		{&Array{Elements: []Object{AsInt(1)}}, &a, "cannot convert ARRAY of length 1 to [3]int"},
+		{&Array{Elements: []Object{TRUE}}, &[]string{}, "index 0: cannot convert BOOLEAN to string"},
+		{&Function{}, &fn, "cannot convert FUNCTION to func()"},
+		{&Builtin{}, &fn, "cannot convert BUILTIN to func(): want at most one result and an error"},
+		{&Builtin{}, &noErr, "cannot convert BUILTIN to func(int) int: want at most one result and an error"},
+		{&Builtin{}, &twoResults, "cannot convert BUILTIN to func() (int, int): want at most one result and an error"},
+	}
+
+	for _, tt := range tests {
+		err := ToGo(tt.obj, tt.target)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("ToGo(%s, %T) wrong error, expected=%q, got=%v",
+				tt.obj.Inspect(), tt.target, tt.expected, err)
+		}
+	}
+}
+
+func testToGo(t *testing.T, obj Object, target any, expected any) {
+	t.Helper()
+
+	if err := ToGo(obj, target); err != nil {
+		t.Errorf("ToGo(%s, %T) failed: %s", obj.Inspect(), target, err)
+		return
+	}
+
+	if expected == nil {
+		return
+	}
+
+	got := reflect.ValueOf(target).Elem().Interface()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ToGo(%s, %T) wrong, expected=%#v, got=%#v",
+			obj.Inspect(), target, expected, got)
+	}
+}
+
+func mustFromGo(t *testing.T, v any) Object {
+	t.Helper()
+
+	obj, err := FromGo(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func mustBuiltin(t *testing.T, fn any) *Builtin {
+	t.Helper()
+	return mustFromGo(t, fn).(*Builtin)
+}
+
+func mustBuiltinFunc(t *testing.T, obj Object) func(int) (int, error) {
+	t.Helper()
+
+	var fn func(int) (int, error)
+	if err := ToGo(obj, &fn); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
@@ -1,21 +1,222 @@
 package object
 
+import (
+	"maps"
+	"slices"
+)
+
+// RedeclareHandler is called when a let statement declares a name that is
+// already bound in the same scope. Returning a non-nil error aborts the
+// declaration; returning nil lets the new binding replace the old one.
+type RedeclareHandler func(name string) *Error
+
+// ForbidRedeclare is a RedeclareHandler that rejects every redeclaration.
+func ForbidRedeclare(name string) *Error {
+	return FormatError("identifier already declared: %s", name)
+}
+
+// Environment binds names to values in a scope. Scopes whose variables
+// are known in advance keep them in slots, in the order of names, so that
+// resolved identifiers are looked up by index; an empty slot is a variable
+// not bound yet. Other names are kept in a map. Binding a Go nil binds
+// NULL, so that bound variables are never nil.
 type Environment struct {
-	store map[string]Object
+	store      map[string]Object
+	consts     map[string]bool
+	names      []string
+	slots      []Object
+	outer      *Environment
+	redeclared RedeclareHandler
 }
 
 func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+// NewEnclosedEnvironment creates a scope nested in outer. Lookups fall back
+// to outer, declarations stay local.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	env.redeclared = outer.redeclared
+	return env
+}
+
+// NewScope creates a scope nested in outer whose variables are names, each
+// bound to its own slot. Names outside of names are still accepted.
+func NewScope(outer *Environment, names []string) *Environment {
 	return &Environment{
-		store: make(map[string]Object),
+		names:      names,
+		slots:      make([]Object, len(names)),
+		outer:      outer,
+		redeclared: outer.redeclared,
 	}
 }
 
-func (e *Environment) Get(name string) (Object, bool) {
+// OnRedeclare sets the handler consulted when a name is redeclared in the
+// same scope. Scopes created from e afterwards inherit it.
+func (e *Environment) OnRedeclare(h RedeclareHandler) {
+	e.redeclared = h
+}
+
+// slot returns the index of the slot of name, or -1 if it has none.
+func (e *Environment) slot(name string) int {
+	for i, n := range e.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookup finds name in the current scope only.
+func (e *Environment) lookup(name string) (Object, bool) {
+	if i := e.slot(name); i >= 0 {
+		return e.slots[i], e.slots[i] != nil
+	}
 	obj, ok := e.store[name]
 	return obj, ok
 }
 
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.lookup(name)
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// Names returns the names bound in the current scope, those with slots in
+// slot order, followed by the others sorted.
+func (e *Environment) Names() []string {
+	var names []string
+	for i, name := range e.names {
+		if e.slots[i] != nil {
+			names = append(names, name)
+		}
+	}
+	return append(names, slices.Sorted(maps.Keys(e.store))...)
+}
+
+// Outer returns the scope e is nested in, nil for a top-level scope.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// GetAt looks up a variable resolved to slot of the scope depth levels
+// out, or to its name there if slot is negative. Variables not bound yet
+// are looked up by name from e, as Get does.
+func (e *Environment) GetAt(depth, slot int, name string) (Object, bool) {
+	scope := e.ancestor(depth)
+	if slot < 0 {
+		return scope.Get(name)
+	}
+	if obj := scope.slots[slot]; obj != nil {
+		return obj, true
+	}
+	return e.Get(name)
+}
+
+func (e *Environment) ancestor(depth int) *Environment {
+	scope := e
+	for range depth {
+		scope = scope.outer
+	}
+	return scope
+}
+
+// Set binds name in the current scope unconditionally.
 func (e *Environment) Set(name string, val Object) Object {
+	return e.SetAt(-1, name, val)
+}
+
+// SetAt binds the variable in slot of the current scope, or name if slot
+// is negative, unconditionally.
+func (e *Environment) SetAt(slot int, name string, val Object) Object {
+	if val == nil {
+		val = NULL
+	}
+	if slot < 0 {
+		slot = e.slot(name)
+	}
+	if slot >= 0 {
+		e.slots[slot] = val
+		return val
+	}
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
 	return val
 }
+
+// Declare binds name in the current scope, enforcing that constants are
+// never redeclared and consulting the redeclare handler otherwise.
+func (e *Environment) Declare(name string, val Object, constant bool) Object {
+	return e.DeclareAt(-1, name, val, constant)
+}
+
+// DeclareAt declares the variable in slot of the current scope, or name if
+// slot is negative, like Declare.
+func (e *Environment) DeclareAt(slot int, name string, val Object, constant bool) Object {
+	if e.consts[name] {
+		return FormatError("cannot redeclare constant: %s", name)
+	}
+
+	if slot < 0 {
+		slot = e.slot(name)
+	}
+	bound := false
+	if slot >= 0 {
+		bound = e.slots[slot] != nil
+	} else {
+		_, bound = e.store[name]
+	}
+	if bound && e.redeclared != nil {
+		if err := e.redeclared(name); err != nil {
+			return err
+		}
+	}
+
+	if constant {
+		if e.consts == nil {
+			e.consts = make(map[string]bool)
+		}
+		e.consts[name] = true
+	}
+	return e.SetAt(slot, name, val)
+}
+
+// Assign rebinds an existing name in the nearest scope that declares it.
+func (e *Environment) Assign(name string, val Object) Object {
+	if _, ok := e.lookup(name); !ok {
+		if e.outer == nil {
+			return FormatError("identifier not found: %s", name)
+		}
+		return e.outer.Assign(name, val)
+	}
+
+	if e.consts[name] {
+		return FormatError("cannot assign to constant: %s", name)
+	}
+	return e.Set(name, val)
+}
+
+// AssignAt rebinds a variable resolved like for GetAt.
+func (e *Environment) AssignAt(depth, slot int, name string, val Object) Object {
+	scope := e.ancestor(depth)
+	if slot < 0 {
+		return scope.Assign(name, val)
+	}
+	if scope.slots[slot] == nil {
+		return e.Assign(name, val)
+	}
+	if scope.consts[name] {
+		return FormatError("cannot assign to constant: %s", name)
+	}
+	if val == nil {
+		val = NULL
+	}
+	scope.slots[slot] = val
+	return val
+}
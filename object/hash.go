@@ -0,0 +1,91 @@
+package object
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// HashKey identifies a hashable object by type and value, so that equal
+// strings or integers find the same pair.
+type HashKey struct {
+	Type  ObjectType
+	Value string
+}
+
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: strconv.FormatInt(i.Value, 10)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	return HashKey{Type: b.Type(), Value: strconv.FormatBool(b.Value)}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: s.Value}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps hashable keys to values and remembers insertion order, so that
+// it inspects and iterates deterministically.
+type Hash struct {
+	pairs map[HashKey]HashPair
+	keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	hk := key.HashKey()
+	if _, ok := h.pairs[hk]; !ok {
+		h.keys = append(h.keys, hk)
+	}
+	h.pairs[hk] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+func (h *Hash) Len() int {
+	return len(h.keys)
+}
+
+// Pairs returns the key/value pairs in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, len(h.keys))
+	for i, hk := range h.keys {
+		pairs[i] = h.pairs[hk]
+	}
+	return pairs
+}
+
+func (_ *Hash) Type() ObjectType {
+	return OBJ_HASH
+}
+
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := make([]string, 0, h.Len())
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteRune('{')
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteRune('}')
+	return out.String()
+}
@@ -0,0 +1,172 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Getter is implemented by values whose members can be read with obj.name.
+type Getter interface {
+	GetMember(name string) (Object, error)
+}
+
+// Setter is implemented by values whose members can be assigned with
+// obj.name = value.
+type Setter interface {
+	SetMember(name string, value Object) error
+}
+
+// Caller is implemented by values with methods callable as obj.name(args).
+type Caller interface {
+	CallMember(name string, args ...Object) (Object, error)
+}
+
+// HostObject exposes a Go value to scripts. Member access is delegated to
+// the value if it implements Getter, Setter or Caller and done through
+// reflection otherwise: fields are named like in FromGo, methods by their
+// Go name. Wrap a pointer to let scripts assign fields and call pointer
+// methods.
+type HostObject struct {
+	value   any
+	members map[string]bool
+}
+
+// NewHostObject wraps v. If members are given, only those are visible to
+// scripts, otherwise every exported field and method is.
+func NewHostObject(v any, members ...string) *HostObject {
+	h := &HostObject{value: v}
+
+	if len(members) > 0 {
+		h.members = make(map[string]bool, len(members))
+		for _, m := range members {
+			h.members[m] = true
+		}
+	}
+	return h
+}
+
+// Value returns the wrapped Go value.
+func (h *HostObject) Value() any {
+	return h.value
+}
+
+func (_ *HostObject) Type() ObjectType {
+	return OBJ_HOST
+}
+
+func (h *HostObject) Inspect() string {
+	return fmt.Sprint(h.value)
+}
+
+func (h *HostObject) GetMember(name string) (Object, error) {
+	if !h.exposes(name) {
+		return nil, h.noMember(name)
+	}
+
+	if getter, ok := h.value.(Getter); ok {
+		return getter.GetMember(name)
+	}
+
+	if field, ok, err := h.field(name); err != nil {
+		return nil, err
+	} else if ok {
+		return FromGo(field.Interface())
+	}
+
+	// a nil value has no methods, and MethodByName panics on its zero Value
+	if v := reflect.ValueOf(h.value); v.IsValid() {
+		if method := v.MethodByName(name); method.IsValid() {
+			return builtinFromGo(method)
+		}
+	}
+	return nil, h.noMember(name)
+}
+
+func (h *HostObject) SetMember(name string, value Object) error {
+	if !h.exposes(name) {
+		return h.noMember(name)
+	}
+
+	if setter, ok := h.value.(Setter); ok {
+		return setter.SetMember(name, value)
+	}
+
+	field, ok, err := h.field(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return h.noMember(name)
+	}
+	if !field.CanSet() {
+		return fmt.Errorf("cannot assign to %s of %T", name, h.value)
+	}
+
+	val, err := toGo(value, field.Type())
+	if err != nil {
+		return err
+	}
+	field.Set(val)
+	return nil
+}
+
+func (h *HostObject) CallMember(name string, args ...Object) (Object, error) {
+	if !h.exposes(name) {
+		return nil, h.noMember(name)
+	}
+
+	if caller, ok := h.value.(Caller); ok {
+		return caller.CallMember(name, args...)
+	}
+
+	member, err := h.GetMember(name)
+	if err != nil {
+		return nil, err
+	}
+
+	builtin, ok := member.(*Builtin)
+	if !ok {
+		return nil, fmt.Errorf("not a function: %s", member.Type())
+	}
+
+	result := builtin.Call(nil, args...)
+	if err, ok := result.(*Error); ok {
+		return nil, fmt.Errorf("%s", err.Msg)
+	}
+	return result, nil
+}
+
+func (h *HostObject) exposes(name string) bool {
+	return h.members == nil || h.members[name]
+}
+
+func (h *HostObject) noMember(name string) error {
+	return fmt.Errorf("%T has no member %s", h.value, name)
+}
+
+// field finds the struct field exposed as name, looking through a pointer.
+// Fields promoted through a nil embedded pointer exist but cannot be
+// reached.
+func (h *HostObject) field(name string) (reflect.Value, bool, error) {
+	v := reflect.ValueOf(h.value)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false, nil
+	}
+
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		if f.Anonymous {
+			continue
+		}
+		if fname, ok := fieldName(f); ok && fname == name {
+			field, err := v.FieldByIndexErr(f.Index)
+			if err != nil {
+				return reflect.Value{}, true, fmt.Errorf("cannot access %s of %T through a nil embedded pointer", name, h.value)
+			}
+			return field, true, nil
+		}
+	}
+	return reflect.Value{}, false, nil
+}
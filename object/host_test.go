@@ -0,0 +1,193 @@
+package object
+
+import (
+	"errors"
+	"testing"
+)
+
+type account struct {
+	Owner   string `monkey:"owner"`
+	Balance int
+	pin     int
+}
+
+func (a *account) Deposit(n int) int {
+	a.Balance += n
+	return a.Balance
+}
+
+func (a *account) Withdraw(n int) (int, error) {
+	if n > a.Balance {
+		return a.Balance, errors.New("insufficient funds")
+	}
+	a.Balance -= n
+	return a.Balance, nil
+}
+
+// Entity is embedded through a pointer, promoting its fields
+type Entity struct {
+	ID int
+}
+
+type user struct {
+	*Entity
+	Name string
+}
+
+// counter implements the member interfaces itself
+type counter struct {
+	n int64
+}
+
+func (c *counter) GetMember(name string) (Object, error) {
+	if name == "value" {
+		return AsInt(c.n), nil
+	}
+	return nil, errors.New("no " + name)
+}
+
+func (c *counter) SetMember(name string, value Object) error {
+	i, ok := value.(*Integer)
+	if name != "value" || !ok {
+		return errors.New("cannot set " + name)
+	}
+	c.n = i.Value
+	return nil
+}
+
+func (c *counter) CallMember(name string, args ...Object) (Object, error) {
+	if name != "inc" {
+		return nil, errors.New("no method " + name)
+	}
+	c.n += 1
+	return AsInt(c.n), nil
+}
+
+func TestHostObjectReflection(t *testing.T) {
+	acc := &account{Owner: "ann", Balance: 10, pin: 1234}
+	h := NewHostObject(acc)
+
+	testMember(t, h, "owner", "ann")
+	testMember(t, h, "Balance", "10")
+
+	if _, err := h.GetMember("pin"); err == nil {
+		t.Error("unexported fields should not be visible")
+	}
+	if _, err := h.GetMember("Owner"); err == nil {
+		t.Error("tagged fields should only be visible by their tag")
+	}
+
+	if err := h.SetMember("Balance", AsInt(20)); err != nil {
+		t.Fatalf("SetMember failed: %s", err)
+	}
+	if acc.Balance != 20 {
+		t.Errorf("SetMember did not update the Go value, got=%d", acc.Balance)
+	}
+
+	if err := h.SetMember("Balance", &String{Value: "x"}); err == nil ||
+		err.Error() != "cannot convert STRING to int" {
+		t.Errorf("expected a conversion error, got=%v", err)
+	}
+
+	result, err := h.CallMember("Deposit", AsInt(5))
+	if err != nil || result.Inspect() != "25" || acc.Balance != 25 {
+		t.Errorf("Deposit wrong, got=%v (%v), balance=%d", result, err, acc.Balance)
+	}
+
+	if _, err := h.CallMember("Withdraw", AsInt(100)); err == nil ||
+		err.Error() != "insufficient funds" {
+		t.Errorf("expected insufficient funds, got=%v", err)
+	}
+
+	if _, err := h.CallMember("owner"); err == nil || err.Error() != "not a function: STRING" {
+		t.Errorf("expected not a function, got=%v", err)
+	}
+
+	if err := NewHostObject(*acc).SetMember("Balance", AsInt(1)); err == nil ||
+		err.Error() != "cannot assign to Balance of object.account" {
+		t.Errorf("fields of a wrapped value should not be assignable, got=%v", err)
+	}
+}
+
+func TestHostObjectNilEmbedded(t *testing.T) {
+	testMember(t, NewHostObject(&user{Entity: &Entity{ID: 7}}), "ID", "7")
+
+	h := NewHostObject(&user{Name: "ann"})
+	testMember(t, h, "Name", "ann")
+
+	msg := "cannot access ID of *object.user through a nil embedded pointer"
+	if _, err := h.GetMember("ID"); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got=%v", msg, err)
+	}
+	if err := h.SetMember("ID", AsInt(1)); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got=%v", msg, err)
+	}
+}
+
+func TestHostObjectNil(t *testing.T) {
+	h := NewHostObject(nil)
+
+	msg := "<nil> has no member x"
+	if _, err := h.GetMember("x"); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got=%v", msg, err)
+	}
+	if err := h.SetMember("x", AsInt(1)); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got=%v", msg, err)
+	}
+	if _, err := h.CallMember("x"); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got=%v", msg, err)
+	}
+}
+
+func TestHostObjectMembers(t *testing.T) {
+	h := NewHostObject(&account{Owner: "ann"}, "owner", "Deposit")
+
+	testMember(t, h, "owner", "ann")
+
+	if _, err := h.GetMember("Balance"); err == nil ||
+		err.Error() != "*object.account has no member Balance" {
+		t.Errorf("Balance should be hidden, got=%v", err)
+	}
+
+	if _, err := h.CallMember("Withdraw", AsInt(1)); err == nil {
+		t.Error("Withdraw should be hidden")
+	}
+
+	if _, err := h.CallMember("Deposit", AsInt(1)); err != nil {
+		t.Errorf("Deposit should be exposed, got=%v", err)
+	}
+}
+
+func TestHostObjectInterfaces(t *testing.T) {
+	c := &counter{n: 1}
+	h := NewHostObject(c)
+
+	testMember(t, h, "value", "1")
+
+	if err := h.SetMember("value", AsInt(5)); err != nil || c.n != 5 {
+		t.Errorf("SetMember wrong, got=%v, n=%d", err, c.n)
+	}
+
+	result, err := h.CallMember("inc")
+	if err != nil || result.Inspect() != "6" {
+		t.Errorf("CallMember wrong, got=%v (%v)", result, err)
+	}
+
+	if _, err := h.GetMember("other"); err == nil || err.Error() != "no other" {
+		t.Errorf("expected the getter's error, got=%v", err)
+	}
+}
+
+func testMember(t *testing.T, h *HostObject, name string, expected string) {
+	t.Helper()
+
+	member, err := h.GetMember(name)
+	if err != nil {
+		t.Errorf("GetMember(%q) failed: %s", name, err)
+		return
+	}
+
+	if member.Inspect() != expected {
+		t.Errorf("GetMember(%q) wrong, expected=%q, got=%q", name, expected, member.Inspect())
+	}
+}
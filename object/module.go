@@ -0,0 +1,35 @@
+package object
+
+import "fmt"
+
+// Module is an imported script. Its top-level bindings are readable as
+// members, module.name, but cannot be assigned from outside.
+type Module struct {
+	Name string
+	Env  *Environment
+}
+
+func (_ *Module) Type() ObjectType {
+	return OBJ_MODULE
+}
+
+func (m *Module) Inspect() string {
+	return fmt.Sprintf("<module %s>", m.Name)
+}
+
+func (m *Module) GetMember(name string) (Object, error) {
+	if val, ok := m.Env.Get(name); ok {
+		return val, nil
+	}
+	return nil, fmt.Errorf("module %s has no member %s", m.Name, name)
+}
+
+// NewModule creates a module implemented in Go, binding each of members as
+// a constant.
+func NewModule(name string, members map[string]Object) *Module {
+	env := NewEnvironment()
+	for member, val := range members {
+		env.Declare(member, val, true)
+	}
+	return &Module{Name: name, Env: env}
+}
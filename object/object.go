@@ -1,6 +1,13 @@
 package object
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+	"strings"
+)
 
 var (
 	NULL  = &Null{}
@@ -25,6 +32,23 @@ func (_ *Integer) Type() ObjectType {
 	return OBJ_INTEGER
 }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	// keep floats recognisable: 2.0 is shown as 2.0 rather than 2
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+func (_ *Float) Type() ObjectType {
+	return OBJ_FLOAT
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -47,7 +71,26 @@ func (_ *Null) Type() ObjectType {
 	return OBJ_NULL
 }
 
+const (
+	// smallInts bounds the integers AsInt shares instead of allocating
+	minSmallInt = -128
+	maxSmallInt = 1023
+)
+
+var smallInts = func() []Integer {
+	ints := make([]Integer, maxSmallInt-minSmallInt+1)
+	for i := range ints {
+		ints[i].Value = int64(i + minSmallInt)
+	}
+	return ints
+}()
+
+// AsInt returns an Integer holding val. Small integers are shared, so the
+// result must not be modified.
 func AsInt(val int64) *Integer {
+	if val >= minSmallInt && val <= maxSmallInt {
+		return &smallInts[val-minSmallInt]
+	}
 	return &Integer{Value: val}
 }
 
@@ -81,8 +124,72 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// Frame is a single entry of an error's stack trace: the function the error
+// unwound through and the position it was executing when it did.
+type Frame struct {
+	Function string
+	Pos      token.Position
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("at %s (%s)", f.Function, f.Pos)
+}
+
+type ErrorKind int
+
+const (
+	ERR_RUNTIME ErrorKind = iota
+	ERR_CANCELLED
+	ERR_STEP_LIMIT
+	ERR_STACK_DEPTH
+	ERR_MEMORY_LIMIT
+	ERR_PERMISSION
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ERR_CANCELLED:
+		return "execution cancelled"
+	case ERR_STEP_LIMIT:
+		return "step limit exceeded"
+	case ERR_STACK_DEPTH:
+		return "stack depth exceeded"
+	case ERR_MEMORY_LIMIT:
+		return "memory limit exceeded"
+	case ERR_PERMISSION:
+		return "permission denied"
+	default:
+		return "runtime error"
+	}
+}
+
+// Fatal reports whether errors of this kind abort the whole evaluation
+// instead of being catchable by the script.
+func (k ErrorKind) Fatal() bool {
+	return k != ERR_RUNTIME && k != ERR_MEMORY_LIMIT && k != ERR_PERMISSION
+}
+
 type Error struct {
-	Msg string
+	Kind ErrorKind
+	Msg  string
+	// Value is what was passed to throw, nil for runtime errors
+	Value Object
+	// Pos is where the error was raised
+	Pos token.Position
+	// Trace lists the functions the error unwound through, innermost first
+	Trace []Frame
+
+	// at is the position in the frame the error is currently unwinding
+	at token.Position
+}
+
+// maxInspectedFrames caps how much of a trace Inspect prints, so that
+// runaway recursion does not produce pages of output.
+const maxInspectedFrames = 20
+
+// NewError creates an error of the given kind described by the kind itself.
+func NewError(kind ErrorKind) *Error {
+	return &Error{Kind: kind, Msg: kind.String()}
 }
 
 func (_ *Error) Type() ObjectType {
@@ -90,7 +197,44 @@ func (_ *Error) Type() ObjectType {
 }
 
 func (e *Error) Inspect() string {
-	return "ERROR: " + e.Msg
+	var out bytes.Buffer
+
+	out.WriteString("ERROR: " + e.Msg)
+	for i, f := range e.Trace {
+		if i == maxInspectedFrames {
+			fmt.Fprintf(&out, "\n\t... %d more", len(e.Trace)-i)
+			break
+		}
+		out.WriteString("\n\t" + f.String())
+	}
+	if e.at.IsValid() {
+		out.WriteString("\n\t" + Frame{Function: "<main>", Pos: e.at}.String())
+	}
+	return out.String()
+}
+
+// Locate records where the error was raised, unless it already knows.
+func (e *Error) Locate(pos token.Position) {
+	if !e.Pos.IsValid() {
+		e.Pos = pos
+		e.at = pos
+	}
+}
+
+// Unwind records that the error left function, which was invoked at
+// callSite.
+func (e *Error) Unwind(function string, callSite token.Position) {
+	e.Trace = append(e.Trace, Frame{Function: function, Pos: e.at})
+	e.at = callSite
+}
+
+// Caught returns the value a catch clause binds: the thrown value, or the
+// message for runtime errors.
+func (e *Error) Caught() Object {
+	if e.Value != nil {
+		return e.Value
+	}
+	return &String{Value: e.Msg}
 }
 
 func FormatError(format string, args ...any) *Error {
@@ -102,3 +246,126 @@ func FormatError(format string, args ...any) *Error {
 func IsError(obj Object) bool {
 	return obj != nil && obj.Type() == OBJ_ERROR
 }
+
+type String struct {
+	Value string
+}
+
+func (_ *String) Type() ObjectType {
+	return OBJ_STRING
+}
+
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+type Function struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+	Name       string
+	// Source names the module the function is defined in, empty for the
+	// main program
+	Source string
+}
+
+func (_ *Function) Type() ObjectType {
+	return OBJ_FUNCTION
+}
+
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+
+	params := make([]string, len(f.Parameters))
+	for i, p := range f.Parameters {
+		params[i] = p.String()
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(f.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+// DisplayName is the name used for the function in stack traces.
+func (f *Function) DisplayName() string {
+	if f.Name == "" {
+		return "<anonymous>"
+	}
+	return f.Name
+}
+
+type BuiltinFunction func(args ...Object) Object
+
+// Applier calls a function value the way a call expression would, letting
+// builtins call back into scripts.
+type Applier func(fn Object, args ...Object) Object
+
+// HigherOrderFunction is a builtin that calls functions it is passed
+// through apply.
+type HigherOrderFunction func(apply Applier, args ...Object) Object
+
+// Allocator charges size bytes to the memory budget of the evaluation
+// calling a builtin, failing if they would exceed it.
+type Allocator func(size int64) *Error
+
+// AllocatingFunction is a builtin creating objects whose size depends on
+// its arguments. It charges them through alloc before creating them, so it
+// stops before exceeding the budget.
+type AllocatingFunction func(alloc Allocator, args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+	// HigherOrder, if set, is called instead of Fn
+	HigherOrder HigherOrderFunction
+	// Allocating, if set, is called instead of Fn
+	Allocating AllocatingFunction
+}
+
+// Call invokes the builtin. Higher order builtins need apply and fail
+// without it. Allocating builtins are called without a budget.
+func (b *Builtin) Call(apply Applier, args ...Object) Object {
+	switch {
+	case b.Allocating != nil:
+		return b.Allocating(func(int64) *Error { return nil }, args...)
+	case b.HigherOrder == nil:
+		return b.Fn(args...)
+	case apply == nil:
+		return FormatError("%s cannot be called from Go", b.Name)
+	default:
+		return b.HigherOrder(apply, args...)
+	}
+}
+
+func (_ *Builtin) Type() ObjectType {
+	return OBJ_BUILTIN
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (_ *Array) Type() ObjectType {
+	return OBJ_ARRAY
+}
+
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := make([]string, len(a.Elements))
+	for i, el := range a.Elements {
+		elements[i] = el.Inspect()
+	}
+
+	out.WriteRune('[')
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteRune(']')
+	return out.String()
+}
@@ -0,0 +1,18 @@
+package object
+
+import "testing"
+
+func TestAsInt(t *testing.T) {
+	for _, val := range []int64{minSmallInt - 1, minSmallInt, -1, 0, 1, maxSmallInt, maxSmallInt + 1} {
+		if got := AsInt(val); got.Value != val {
+			t.Errorf("AsInt(%d) holds %d", val, got.Value)
+		}
+	}
+
+	if AsInt(42) != AsInt(42) {
+		t.Errorf("small integers should be shared")
+	}
+	if AsInt(maxSmallInt+1) == AsInt(maxSmallInt+1) {
+		t.Errorf("large integers should not be shared")
+	}
+}
@@ -8,4 +8,15 @@ const (
 	OBJ_NULL                    = "NULL"
 	OBJ_RETURN_VALUE            = "RETURN_VALUE"
 	OBJ_ERROR                   = "ERROR"
+	OBJ_STRING                  = "STRING"
+	OBJ_FUNCTION                = "FUNCTION"
+	OBJ_BUILTIN                 = "BUILTIN"
+	OBJ_FLOAT                   = "FLOAT"
+	OBJ_ARRAY                   = "ARRAY"
+	OBJ_HASH                    = "HASH"
+	OBJ_HOST                    = "HOST"
+	OBJ_MODULE                  = "MODULE"
+	OBJ_TIME                    = "TIME"
+	OBJ_DURATION                = "DURATION"
+	OBJ_REGEX                   = "REGEX"
 )
@@ -0,0 +1,30 @@
+package object
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Regex is a compiled regular expression.
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (_ *Regex) Type() ObjectType {
+	return OBJ_REGEX
+}
+
+func (r *Regex) Inspect() string {
+	return fmt.Sprintf("regex(%q)", r.Value.String())
+}
+
+func (r *Regex) GetMember(name string) (Object, error) {
+	switch name {
+	case "pattern":
+		return &String{Value: r.Value.String()}, nil
+	case "groups":
+		return AsInt(int64(r.Value.NumSubexp())), nil
+	default:
+		return nil, fmt.Errorf("%s has no member %s", OBJ_REGEX, name)
+	}
+}
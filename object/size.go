@@ -0,0 +1,46 @@
+package object
+
+// Rough per-object costs in bytes, modelled on the Go representation: an
+// object header for the struct itself plus whatever it owns directly.
+const (
+	sizeHeader    = 16
+	sizeReference = 8
+)
+
+// SizeOf estimates the memory obj holds on its own, not counting the
+// objects it refers to, which are accounted when they are created.
+func SizeOf(obj Object) int64 {
+	switch obj := obj.(type) {
+	case *String:
+		return StringSize(len(obj.Value))
+	case *Array:
+		return ArraySize(len(obj.Elements))
+	case *Hash:
+		return HashSize(obj.Len())
+	case *Function:
+		// the closure keeps its parameters and a reference to its environment
+		return sizeHeader + sizeReference*int64(len(obj.Parameters)+1)
+	case *Boolean, *Null, *Error, nil:
+		// booleans and null are shared, errors are not program data
+		return 0
+	default:
+		return sizeHeader
+	}
+}
+
+// StringSize, ArraySize and HashSize are what SizeOf returns for a string
+// of n bytes, an array of n elements and a hash of n pairs, so builtins can
+// charge for objects before creating them.
+
+func StringSize(n int) int64 {
+	return sizeHeader + int64(n)
+}
+
+func ArraySize(n int) int64 {
+	return sizeHeader + sizeReference*int64(n)
+}
+
+func HashSize(n int) int64 {
+	// every pair holds its key, value and an entry in the key order
+	return sizeHeader + (3*sizeReference+sizeHeader)*int64(n)
+}
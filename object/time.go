@@ -0,0 +1,76 @@
+package object
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time is an instant, with members for its calendar fields.
+type Time struct {
+	Value time.Time
+}
+
+func (_ *Time) Type() ObjectType {
+	return OBJ_TIME
+}
+
+func (t *Time) Inspect() string {
+	return t.Value.Format(time.RFC3339Nano)
+}
+
+func (t *Time) GetMember(name string) (Object, error) {
+	switch name {
+	case "year":
+		return AsInt(int64(t.Value.Year())), nil
+	case "month":
+		return AsInt(int64(t.Value.Month())), nil
+	case "day":
+		return AsInt(int64(t.Value.Day())), nil
+	case "hour":
+		return AsInt(int64(t.Value.Hour())), nil
+	case "minute":
+		return AsInt(int64(t.Value.Minute())), nil
+	case "second":
+		return AsInt(int64(t.Value.Second())), nil
+	case "nanosecond":
+		return AsInt(int64(t.Value.Nanosecond())), nil
+	case "weekday":
+		return &String{Value: t.Value.Weekday().String()}, nil
+	case "yearday":
+		return AsInt(int64(t.Value.YearDay())), nil
+	case "unix":
+		return AsInt(t.Value.Unix()), nil
+	default:
+		return nil, fmt.Errorf("%s has no member %s", OBJ_TIME, name)
+	}
+}
+
+// Duration is the time elapsed between two instants.
+type Duration struct {
+	Value time.Duration
+}
+
+func (_ *Duration) Type() ObjectType {
+	return OBJ_DURATION
+}
+
+func (d *Duration) Inspect() string {
+	return d.Value.String()
+}
+
+func (d *Duration) GetMember(name string) (Object, error) {
+	switch name {
+	case "hours":
+		return &Float{Value: d.Value.Hours()}, nil
+	case "minutes":
+		return &Float{Value: d.Value.Minutes()}, nil
+	case "seconds":
+		return &Float{Value: d.Value.Seconds()}, nil
+	case "milliseconds":
+		return AsInt(d.Value.Milliseconds()), nil
+	case "nanoseconds":
+		return AsInt(d.Value.Nanoseconds()), nil
+	default:
+		return nil, fmt.Errorf("%s has no member %s", OBJ_DURATION, name)
+	}
+}
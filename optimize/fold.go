@@ -0,0 +1,140 @@
+package optimize
+
+import (
+	"monkey/ast"
+)
+
+// maxFoldedString bounds the strings concatenations are folded into, so
+// that programs doubling a constant string do not build huge literals
+// before the evaluator can limit their memory.
+const maxFoldedString = 256
+
+// foldPrefix returns the literal prefix evaluates to, or nil if its
+// operand is not a literal or the operation fails.
+func foldPrefix(prefix *ast.PrefixExpression) ast.Expression {
+	pos := prefix.Token.Pos
+
+	switch prefix.Operator {
+	case "!":
+		// every literal but false is truthy
+		if b, ok := prefix.Right.(*ast.Boolean); ok {
+			return newBoolean(pos, !b.Value)
+		}
+		if isLiteral(prefix.Right) {
+			return newBoolean(pos, false)
+		}
+	case "-":
+		switch right := prefix.Right.(type) {
+		case *ast.IntegerLiteral:
+			return newInteger(pos, -right.Value)
+		case *ast.FloatLiteral:
+			return newFloat(pos, -right.Value)
+		}
+	}
+	return nil
+}
+
+// foldInfix returns the literal infix evaluates to, or nil if an operand
+// is not a literal or the operation fails, following the evaluator.
+func foldInfix(infix *ast.InfixExpression) ast.Expression {
+	pos := infix.Token.Pos
+
+	switch left := infix.Left.(type) {
+	case *ast.IntegerLiteral:
+		switch right := infix.Right.(type) {
+		case *ast.IntegerLiteral:
+			return foldIntegers(infix, left.Value, right.Value)
+		case *ast.FloatLiteral:
+			return foldFloats(infix, float64(left.Value), right.Value)
+		}
+	case *ast.FloatLiteral:
+		switch right := infix.Right.(type) {
+		case *ast.IntegerLiteral:
+			return foldFloats(infix, left.Value, float64(right.Value))
+		case *ast.FloatLiteral:
+			return foldFloats(infix, left.Value, right.Value)
+		}
+	case *ast.StringLiteral:
+		right, ok := infix.Right.(*ast.StringLiteral)
+		if !ok {
+			return nil
+		}
+		switch infix.Operator {
+		case "+":
+			if len(left.Value)+len(right.Value) > maxFoldedString {
+				return nil
+			}
+			return newString(pos, left.Value+right.Value)
+		case "==":
+			return newBoolean(pos, left.Value == right.Value)
+		case "!=":
+			return newBoolean(pos, left.Value != right.Value)
+		}
+	case *ast.Boolean:
+		right, ok := infix.Right.(*ast.Boolean)
+		if !ok {
+			return nil
+		}
+		switch infix.Operator {
+		case "==":
+			return newBoolean(pos, left.Value == right.Value)
+		case "!=":
+			return newBoolean(pos, left.Value != right.Value)
+		}
+	}
+	return nil
+}
+
+func foldIntegers(infix *ast.InfixExpression, left, right int64) ast.Expression {
+	pos := infix.Token.Pos
+
+	switch infix.Operator {
+	case "+":
+		return newInteger(pos, left+right)
+	case "-":
+		return newInteger(pos, left-right)
+	case "*":
+		return newInteger(pos, left*right)
+	case "/":
+		// division by zero is left to fail at runtime
+		if right == 0 {
+			return nil
+		}
+		return newInteger(pos, left/right)
+	case "<":
+		return newBoolean(pos, left < right)
+	case ">":
+		return newBoolean(pos, left > right)
+	case "==":
+		return newBoolean(pos, left == right)
+	case "!=":
+		return newBoolean(pos, left != right)
+	default:
+		return nil
+	}
+}
+
+func foldFloats(infix *ast.InfixExpression, left, right float64) ast.Expression {
+	pos := infix.Token.Pos
+
+	switch infix.Operator {
+	case "+":
+		return newFloat(pos, left+right)
+	case "-":
+		return newFloat(pos, left-right)
+	case "*":
+		return newFloat(pos, left*right)
+	case "/":
+		return newFloat(pos, left/right)
+	case "<":
+		return newBoolean(pos, left < right)
+	case ">":
+		return newBoolean(pos, left > right)
+	case "==":
+		return newBoolean(pos, left == right)
+	case "!=":
+		return newBoolean(pos, left != right)
+	default:
+		return nil
+	}
+}
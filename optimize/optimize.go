@@ -0,0 +1,281 @@
+// Package optimize rewrites programs into equivalent ones that do less
+// work when evaluated. It folds arithmetic, comparisons and negations of
+// literals, removes the branches of if expressions that cannot be taken
+// and replaces references to constants bound to literals by the literals.
+//
+// Operations that fail at runtime, such as 1 / 0 or 1 + true, are left as
+// they are so that they still fail with the same error.
+package optimize
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+)
+
+// Optimize rewrites program in place and returns it.
+func Optimize(program *ast.Program) *ast.Program {
+	o := &optimizer{}
+	o.openScope(program.Statements)
+	program.Statements = o.stmts(program.Statements)
+	o.closeScope()
+	return program
+}
+
+type scope struct {
+	outer *scope
+	// values holds the names bound so far, to their literal value for
+	// constants and to nil otherwise
+	values map[string]ast.Expression
+	// declared holds the names declared anywhere in the scope, which
+	// functions may refer to before the declaration is reached
+	declared map[string]bool
+}
+
+type optimizer struct {
+	scope *scope
+}
+
+func (o *optimizer) openScope(stmts []ast.Statement, names ...*ast.Identifier) {
+	s := &scope{
+		outer:    o.scope,
+		values:   make(map[string]ast.Expression),
+		declared: make(map[string]bool),
+	}
+	for _, name := range names {
+		s.values[name.Value] = nil
+	}
+	for _, name := range declarations(stmts) {
+		s.declared[name] = true
+	}
+	o.scope = s
+}
+
+func (o *optimizer) closeScope() {
+	o.scope = o.scope.outer
+}
+
+// declarations returns the names the let, const and import statements in
+// stmts declare.
+func declarations(stmts []ast.Statement) []string {
+	var names []string
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.ImportStatement:
+			names = append(names, stmt.Name.Value)
+		}
+	}
+	return names
+}
+
+// constant returns the literal the constant name refers to, or nil if the
+// name may refer to something else, such as a variable declared later in
+// an enclosing scope.
+func (o *optimizer) constant(name string) ast.Expression {
+	for s := o.scope; s != nil; s = s.outer {
+		if val, ok := s.values[name]; ok {
+			return val
+		}
+		if s.declared[name] {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (o *optimizer) block(block *ast.BlockStatement, names ...*ast.Identifier) {
+	if block == nil {
+		return
+	}
+	o.openScope(block.Statements, names...)
+	block.Statements = o.stmts(block.Statements)
+	o.closeScope()
+}
+
+func (o *optimizer) stmts(stmts []ast.Statement) []ast.Statement {
+	out := make([]ast.Statement, 0, len(stmts))
+	for i, stmt := range stmts {
+		stmt = o.stmt(stmt)
+
+		// the value of a statement other than the last is not used, so a
+		// branch known to be taken can be inlined if it declares nothing
+		if es, ok := stmt.(*ast.ExpressionStatement); ok && i < len(stmts)-1 {
+			if ifExp, ok := es.Expression.(*ast.IfExpression); ok {
+				if cond, ok := ifExp.Condition.(*ast.Boolean); ok && len(declarations(ifExp.Consequence.Statements)) == 0 {
+					if cond.Value {
+						out = append(out, ifExp.Consequence.Statements...)
+					}
+					continue
+				}
+			}
+		}
+		out = append(out, stmt)
+	}
+	return out
+}
+
+func (o *optimizer) stmt(stmt ast.Statement) ast.Statement {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		stmt.Value = o.expr(stmt.Value)
+		if stmt.IsConst() && isLiteral(stmt.Value) {
+			o.scope.values[stmt.Name.Value] = stmt.Value
+		} else {
+			o.scope.values[stmt.Name.Value] = nil
+		}
+	case *ast.ImportStatement:
+		o.scope.values[stmt.Name.Value] = nil
+	case *ast.ReturnStatement:
+		stmt.ReturnValue = o.expr(stmt.ReturnValue)
+	case *ast.ExpressionStatement:
+		stmt.Expression = o.expr(stmt.Expression)
+	case *ast.BlockStatement:
+		o.block(stmt)
+	}
+	return stmt
+}
+
+func (o *optimizer) expr(expr ast.Expression) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if val := o.constant(expr.Value); val != nil {
+			return relocate(val, expr.Token.Pos)
+		}
+	case *ast.AssignExpression:
+		// the target is not a use of a constant, assigning to one has to
+		// fail at runtime
+		if _, ok := expr.Target.(*ast.Identifier); !ok {
+			expr.Target = o.expr(expr.Target)
+		}
+		expr.Value = o.expr(expr.Value)
+	case *ast.PrefixExpression:
+		expr.Right = o.expr(expr.Right)
+		if folded := foldPrefix(expr); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		expr.Left = o.expr(expr.Left)
+		expr.Right = o.expr(expr.Right)
+		if folded := foldInfix(expr); folded != nil {
+			return folded
+		}
+	case *ast.IfExpression:
+		return o.ifExpr(expr)
+	case *ast.FunctionLiteral:
+		o.block(expr.Body, expr.Parameters...)
+	case *ast.CallExpression:
+		expr.Func = o.expr(expr.Func)
+		o.exprs(expr.Args)
+	case *ast.TryExpression:
+		o.block(expr.Block)
+		if expr.Param != nil {
+			o.block(expr.Catch, expr.Param)
+		} else {
+			o.block(expr.Catch)
+		}
+		o.block(expr.Finally)
+	case *ast.ArrayLiteral:
+		o.exprs(expr.Elements)
+	case *ast.IndexExpression:
+		expr.Left = o.expr(expr.Left)
+		expr.Index = o.expr(expr.Index)
+	case *ast.DotExpression:
+		expr.Left = o.expr(expr.Left)
+	case *ast.HashLiteral:
+		for i := range expr.Pairs {
+			expr.Pairs[i].Key = o.expr(expr.Pairs[i].Key)
+			expr.Pairs[i].Value = o.expr(expr.Pairs[i].Value)
+		}
+	}
+	return expr
+}
+
+func (o *optimizer) exprs(exprs []ast.Expression) {
+	for i, expr := range exprs {
+		exprs[i] = o.expr(expr)
+	}
+}
+
+// ifExpr removes the branch that cannot be taken if the condition is a
+// literal. The other one is kept in a block of its own, since its
+// bindings must not leak, unless it is a single expression.
+func (o *optimizer) ifExpr(ifExp *ast.IfExpression) ast.Expression {
+	ifExp.Condition = o.expr(ifExp.Condition)
+	if !isLiteral(ifExp.Condition) {
+		o.block(ifExp.Consequence)
+		o.block(ifExp.Alternative)
+		return ifExp
+	}
+
+	taken := ifExp.Consequence
+	if b, ok := ifExp.Condition.(*ast.Boolean); ok && !b.Value {
+		taken = ifExp.Alternative
+	}
+	if taken == nil {
+		// the value is null, as for an if (false) without statements
+		ifExp.Condition = newBoolean(ifExp.Token.Pos, false)
+		ifExp.Consequence = &ast.BlockStatement{Token: ifExp.Consequence.Token}
+		ifExp.Alternative = nil
+		return ifExp
+	}
+
+	o.block(taken)
+	if len(taken.Statements) == 1 {
+		if es, ok := taken.Statements[0].(*ast.ExpressionStatement); ok {
+			return es.Expression
+		}
+	}
+	ifExp.Condition = newBoolean(ifExp.Token.Pos, true)
+	ifExp.Consequence = taken
+	ifExp.Alternative = nil
+	return ifExp
+}
+
+func isLiteral(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	default:
+		return false
+	}
+}
+
+// relocate copies the literal lit to pos.
+func relocate(lit ast.Expression, pos token.Position) ast.Expression {
+	switch lit := lit.(type) {
+	case *ast.IntegerLiteral:
+		return newInteger(pos, lit.Value)
+	case *ast.FloatLiteral:
+		return newFloat(pos, lit.Value)
+	case *ast.StringLiteral:
+		return newString(pos, lit.Value)
+	case *ast.Boolean:
+		return newBoolean(pos, lit.Value)
+	default:
+		return lit
+	}
+}
+
+func newInteger(pos token.Position, val int64) *ast.IntegerLiteral {
+	tok := token.Token{Type: token.INT, Literal: strconv.FormatInt(val, 10), Pos: pos}
+	return &ast.IntegerLiteral{Token: tok, Value: val}
+}
+
+func newFloat(pos token.Position, val float64) *ast.FloatLiteral {
+	tok := token.Token{Type: token.FLOAT, Literal: strconv.FormatFloat(val, 'f', -1, 64), Pos: pos}
+	return &ast.FloatLiteral{Token: tok, Value: val}
+}
+
+func newString(pos token.Position, val string) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: val, Pos: pos}, Value: val}
+}
+
+func newBoolean(pos token.Position, val bool) *ast.Boolean {
+	tok := token.Token{Type: token.FALSE, Literal: "false", Pos: pos}
+	if val {
+		tok = token.Token{Type: token.TRUE, Literal: "true", Pos: pos}
+	}
+	return &ast.Boolean{Token: tok, Value: val}
+}
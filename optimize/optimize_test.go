@@ -0,0 +1,98 @@
+package optimize
+
+import (
+	"monkey/ast"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`2 * (5 + 10)`, `30`},
+		{`1 + 2.5`, `3.5`},
+		{`10 / 3 - 1`, `2`},
+		{`"a" + "b" == "ab"`, `true`},
+		{`!true`, `false`},
+		{`!!5`, `true`},
+		{`-(2 * 3)`, `-6`},
+		{`1 < 2 == true`, `true`},
+		{`x + 2 * 3`, `(x + 6)`},
+		{`1 / 0`, `(1 / 0)`},
+		{`1 + true`, `(1 + true)`},
+		{`"a" - "b"`, `(a - b)`},
+		{`if (true) { 1 } else { 2 }`, `1`},
+		{`if (1 > 2) { 1 } else { x }`, `x`},
+		{`if (false) { 1 }`, `iffalse `},
+		{`if (true) { let a = 1; a }`, `iftrue let a = 1;a`},
+		{`if (true) { puts(1) } 2`, `puts(1)2`},
+		{`if (false) { puts(1) } 2`, `2`},
+		{`if (x) { 1 + 1 } else { 2 * 2 }`, `ifx 2else 4`},
+		{`const n = 2 * 3; n * n`, `const n = 6;36`},
+		{`let n = 2; n * n`, `let n = 2;(n * n)`},
+		{`const n = 1; n = 2`, `const n = 1;n = 2`},
+		{`const n = 1; let f = fn(n) { n }; f(n)`, `const n = 1;let f = fn(n)n;f(1)`},
+		{`const n = 1; if (x) { let n = 2; n }`, `const n = 1;ifx let n = 2;n`},
+		{`const n = 1; let f = fn() { let g = fn() { n }; let n = 2; g() }`,
+			`const n = 1;let f = fn()let g = fn()n;let n = 2;g();`},
+		{`let f = fn() { n }; const n = 1; f()`, `let f = fn()n;const n = 1;f()`},
+		{`{"a" + "b": [1 + 1]}`, `{ab: [2]}`},
+		{`const a = "` + strings.Repeat("a", 200) + `"; const b = a + "b"; b + b`,
+			`const a = ` + strings.Repeat("a", 200) + `;const b = ` + strings.Repeat("a", 200) + `b;(` +
+				strings.Repeat("a", 200) + `b + ` + strings.Repeat("a", 200) + `b)`},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q, want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+// TestSemantics checks that optimised programs evaluate to the same value
+// or error as the original ones.
+func TestSemantics(t *testing.T) {
+	inputs := []string{
+		`let x = 4; if (x > 2) { x * (1 + 1) } else { 0 }`,
+		`const limit = 10; let count = fn(n) { if (n > limit) { n } else { count(n + 1) } }; count(0)`,
+		`if (false) { 1 }`,
+		`let f = fn() { if (true) { return 1 } 2 }; f()`,
+		`1 / (2 - 2)`,
+		`let x = 1; x + (true == false)`,
+		`const c = 1; c = 2`,
+		`const c = "a"; let s = fn(x) { c + x }; s("b") + c`,
+		`if (true) { let a = 1; } a`,
+		`-(1.5 * 2) + 3`,
+	}
+
+	for _, input := range inputs {
+		want := eval.Eval(parse(t, input), object.NewEnvironment())
+		got := eval.Eval(Optimize(parse(t, input)), object.NewEnvironment())
+		if want.Inspect() != got.Inspect() {
+			t.Errorf("optimised %q evaluates differently, want=%s, got=%s", input, want.Inspect(), got.Inspect())
+		}
+		if werr, ok := want.(*object.Error); ok {
+			if gerr, ok := got.(*object.Error); !ok || werr.Pos != gerr.Pos {
+				t.Errorf("optimised %q fails elsewhere, want=%v, got=%v", input, werr.Pos, got)
+			}
+		}
+	}
+}
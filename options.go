@@ -0,0 +1,74 @@
+package monkey
+
+import (
+	"io"
+	"io/fs"
+	"monkey/eval"
+	"monkey/object"
+	"monkey/std"
+	"time"
+)
+
+type Option func(*Interpreter)
+
+// WithStdout sets where puts writes to, os.Stdout by default.
+func WithStdout(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// WithBuiltin makes fn callable from scripts as name. It takes precedence
+// over a default builtin of the same name.
+func WithBuiltin(name string, fn object.BuiltinFunction) Option {
+	return func(i *Interpreter) {
+		i.builtins[name] = &object.Builtin{Name: name, Fn: fn}
+	}
+}
+
+// WithLimits bounds every Eval and Call, see eval.Limits.
+func WithLimits(limits eval.Limits) Option {
+	return func(i *Interpreter) {
+		i.limits = limits
+	}
+}
+
+// WithModules lets scripts import modules from the files of fsys, see
+// eval.FSLoader. Modules are cached for the lifetime of the interpreter.
+func WithModules(fsys fs.FS) Option {
+	return func(i *Interpreter) {
+		i.modules = eval.NewModules(eval.FSLoader{FS: fsys})
+	}
+}
+
+// WithFS lets scripts read the files of fsys through the fs module, and
+// modify them if fsys is a std.WritableFS. Without it, or WithRootDir,
+// every file system access fails with a permission error.
+func WithFS(fsys fs.FS) Option {
+	return func(i *Interpreter) {
+		i.imports["fs"] = std.NewFS(fsys)
+	}
+}
+
+// WithRootDir lets scripts read and write the files below dir through the
+// fs module.
+func WithRootDir(dir string) Option {
+	return WithFS(std.DirFS(dir))
+}
+
+// WithClock makes time.now in scripts return now() instead of the current
+// time, so that their output can be tested deterministically.
+func WithClock(now func() time.Time) Option {
+	return func(i *Interpreter) {
+		i.imports["time"] = std.NewTime(now)
+	}
+}
+
+// WithOptimization rewrites programs with optimize.Optimize before they are
+// evaluated, folding constant expressions once instead of on every
+// evaluation.
+func WithOptimization() Option {
+	return func(i *Interpreter) {
+		i.optimize = true
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
+	"path"
+	"strings"
 )
 
 type (
@@ -41,6 +43,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefixParser(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefixParser(token.IF, p.parseIfExpression)
 	p.registerPrefixParser(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefixParser(token.STRING, p.parseStringLiteral)
+	p.registerPrefixParser(token.ILLEGAL, p.parseIllegal)
+	p.registerPrefixParser(token.TRY, p.parseTryExpression)
+	p.registerPrefixParser(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefixParser(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefixParser(token.LCURLY, p.parseHashLiteral)
 
 	p.registerInfixParser(token.PLUS, p.parseInfixExpression)
 	p.registerInfixParser(token.MINUS, p.parseInfixExpression)
@@ -51,6 +59,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfixParser(token.LT, p.parseInfixExpression)
 	p.registerInfixParser(token.GT, p.parseInfixExpression)
 	p.registerInfixParser(token.LPAREN, p.parseCallExpression)
+	p.registerInfixParser(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfixParser(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfixParser(token.DOT, p.parseDotExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -83,10 +94,12 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 func (p *Parser) parseStmt() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStmt()
 	case token.RETURN:
 		return p.parseReturnStmt()
+	case token.IMPORT:
+		return p.parseImportStmt()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -103,12 +116,23 @@ func (p *Parser) parseLetStmt() *ast.LetStatement {
 		Value: p.curToken.Literal,
 	}
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if stmt.Type = p.parseType(); stmt.Type == nil {
+			return nil
+		}
+	}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(PRECEDENCE_LOWEST)
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fn.Name = stmt.Name.Value
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -116,6 +140,47 @@ func (p *Parser) parseLetStmt() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseImportStmt() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = p.curToken.Literal
+
+	// as is only special here, so it stays usable as an identifier
+	if p.peekTokenIs(token.IDENTIFIER) && p.peekToken.Literal == "as" {
+		p.nextToken()
+		if !p.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Alias = true
+	} else {
+		name := path.Base(stmt.Path)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if !isIdentifier(name) {
+			p.addError(fmt.Sprintf("cannot name module %q, use import %q as name",
+				stmt.Path, stmt.Path))
+			return nil
+		}
+		stmt.Name = &ast.Identifier{
+			Token: token.Token{Type: token.IDENTIFIER, Literal: name, Pos: stmt.Token.Pos},
+			Value: name,
+		}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// isIdentifier reports whether name lexes as a single identifier.
+func isIdentifier(name string) bool {
+	tok := lexer.New(name).NextToken()
+	return tok.Type == token.IDENTIFIER && tok.Literal == name
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -159,10 +224,8 @@ func (p *Parser) registerInfixParser(tt token.TokenType, ip infixParser) {
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{
-		Token:      p.curToken,
-		Expression: p.parseExpression(PRECEDENCE_LOWEST),
-	}
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(PRECEDENCE_LOWEST)
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -226,6 +289,22 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.DotExpression:
+	default:
+		p.addError(fmt.Sprintf("cannot assign to %s", target))
+		return nil
+	}
+	p.nextToken()
+
+	// assignment is right-associative: a = b = c is a = (b = c)
+	exp.Value = p.parseExpression(PRECEDENCE_LOWEST)
+	return exp
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -273,6 +352,50 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseTryExpression() ast.Expression {
+	exp := &ast.TryExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LCURLY) {
+		return nil
+	}
+	exp.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENTIFIER) {
+				return nil
+			}
+			exp.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.LCURLY) {
+			return nil
+		}
+		exp.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LCURLY) {
+			return nil
+		}
+		exp.Finally = p.parseBlockStatement()
+	}
+
+	if exp.Catch == nil && exp.Finally == nil {
+		p.addError("expected catch or finally after try block")
+		return nil
+	}
+	return exp
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -297,7 +420,14 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.ParamTypes = p.parseFunctionParameters()
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if lit.Result = p.parseType(); lit.Result == nil {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.LCURLY) {
 		return nil
@@ -307,61 +437,188 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// parseFunctionParameters parses the parameters and their optional type
+// annotations, returning nil types if none is annotated.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.Type) {
 	identifiers := []*ast.Identifier{}
+	var types []ast.Type
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return identifiers, nil
 	}
-	p.nextToken()
-
-	identifiers = append(identifiers, &ast.Identifier{
-		Token: p.curToken, Value: p.curToken.Literal,
-	})
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+	for {
 		p.nextToken()
 		identifiers = append(identifiers, &ast.Identifier{
 			Token: p.curToken, Value: p.curToken.Literal,
 		})
+
+		var typ ast.Type
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			if typ = p.parseType(); typ == nil {
+				return nil, nil
+			}
+			if types == nil {
+				types = make([]ast.Type, len(identifiers)-1, len(identifiers))
+			}
+		}
+		if types != nil {
+			types = append(types, typ)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RPAREN) {
+		return nil, nil
+	}
+	return identifiers, types
+}
+
+// parseType parses the type annotation following the current token:
+// a name, [T], {K: V} or fn(A, B): R.
+func (p *Parser) parseType() ast.Type {
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.IDENTIFIER:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+
+	case token.LBRACKET:
+		typ := &ast.ArrayType{Token: p.curToken}
+		if typ.Element = p.parseType(); typ.Element == nil || !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return typ
+
+	case token.LCURLY:
+		typ := &ast.HashType{Token: p.curToken}
+		if typ.Key = p.parseType(); typ.Key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+		if typ.Value = p.parseType(); typ.Value == nil || !p.expectPeek(token.RCURLY) {
+			return nil
+		}
+		return typ
+
+	case token.FUNCTION:
+		typ := &ast.FunctionType{Token: p.curToken, Params: []ast.Type{}}
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		for !p.peekTokenIs(token.RPAREN) {
+			param := p.parseType()
+			if param == nil {
+				return nil
+			}
+			typ.Params = append(typ.Params, param)
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			if typ.Result = p.parseType(); typ.Result == nil {
+				return nil
+			}
+		}
+		return typ
+
+	default:
+		p.addError(fmt.Sprintf("expected a type, got %s instead", p.curToken.Type))
 		return nil
 	}
-	return identifiers
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
-	return &ast.CallExpression{
-		Token: p.curToken,
-		Func:  function,
-		Args:  p.parseCallArguments(),
-	}
+	exp := &ast.CallExpression{Token: p.curToken, Func: function}
+	exp.Args = p.parseExpressionList(token.RPAREN)
+	return exp
 }
 
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
 
-	if p.peekTokenIs(token.RPAREN) {
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(PRECEDENCE_LOWEST))
+	list = append(list, p.parseExpression(PRECEDENCE_LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(PRECEDENCE_LOWEST))
+		list = append(list, p.parseExpression(PRECEDENCE_LOWEST))
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(PRECEDENCE_LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	exp := &ast.DotExpression{Token: p.curToken, Left: left}
+
+	if !p.expectPeek(token.IDENTIFIER) {
 		return nil
 	}
+	exp.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Pairs: []ast.HashPair{}}
+
+	for !p.peekTokenIs(token.RCURLY) {
+		p.nextToken()
+		key := p.parseExpression(PRECEDENCE_LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
 
-	return args
+		p.nextToken()
+		value := p.parseExpression(PRECEDENCE_LOWEST)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RCURLY) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RCURLY) {
+		return nil
+	}
+	return hash
 }
@@ -369,6 +369,26 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a.b.c",
+			"a.b.c",
+		},
+		{
+			"-a.b * c.d(1)",
+			"((-a.b) * c.d(1))",
+		},
+		{
+			"a.b[0].c(x)[1]",
+			"((a.b[0]).c(x)[1])",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -703,3 +723,394 @@ func TestCallExpressionParsing(t *testing.T) {
 	testInfixExpression(t, exp.Args[1], 2, "*", 3)
 	testInfixExpression(t, exp.Args[2], 4, "+", 5)
 }
+
+func TestConstStatements(t *testing.T) {
+	p := New(lexer.New("const x = 5;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program does not have enough statements, got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.LetStatement, got=%T", program.Statements[0])
+	}
+
+	if !stmt.IsConst() {
+		t.Errorf("stmt.IsConst() should be true for %q", stmt)
+	}
+
+	if stmt.String() != "const x = 5;" {
+		t.Errorf("stmt.String() wrong, got=%q", stmt.String())
+	}
+
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"x = y = 5", "x = y = 5"},
+		{"x = 1 + 2 * 3", "x = (1 + (2 * 3))"},
+		{"x = a == b", "x = (a == b)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.AssignExpression); !ok {
+			t.Errorf("stmt.Expression is not an ast.AssignExpression, got=%T",
+				stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	p := New(lexer.New("1 + 2 = 3"))
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected parser errors for invalid assignment target")
+	}
+
+	if p.Errors()[0] != "cannot assign to (1 + 2)" {
+		t.Errorf("wrong error, got=%q", p.Errors()[0])
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	p := New(lexer.New(`"hello world";`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral, got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value not %q, got=%q", "hello world", literal.Value)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	tests := []string{`let s = "abc`, `let s = "abc\`}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 || p.Errors()[0] != "unterminated string at 1:9" {
+			t.Errorf("%s: expected an unterminated string error, got=%v", input, p.Errors())
+		}
+	}
+}
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	p := New(lexer.New("let myFunction = fn() { };"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.LetStatement)
+	fn, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral, got=%T", stmt.Value)
+	}
+
+	if fn.Name != "myFunction" {
+		t.Errorf("function literal name wrong, want 'myFunction', got=%q", fn.Name)
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input      string
+		param      string
+		hasCatch   bool
+		hasFinally bool
+	}{
+		{"try { x } catch (e) { y }", "e", true, false},
+		{"try { x } catch { y }", "", true, false},
+		{"try { x } finally { z }", "", false, true},
+		{"try { x } catch (err) { y } finally { z }", "err", true, true},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not an ast.TryExpression, got=%T",
+				stmt.Expression)
+		}
+
+		if !testIdentifier(t, exp.Block.Statements[0].(*ast.ExpressionStatement).Expression, "x") {
+			return
+		}
+
+		if tt.param == "" && exp.Param != nil {
+			t.Errorf("exp.Param should be nil, got=%s", exp.Param)
+		}
+		if tt.param != "" && !testIdentifier(t, exp.Param, tt.param) {
+			return
+		}
+
+		if (exp.Catch != nil) != tt.hasCatch {
+			t.Errorf("catch block presence wrong for %q", tt.input)
+		}
+		if (exp.Finally != nil) != tt.hasFinally {
+			t.Errorf("finally block presence wrong for %q", tt.input)
+		}
+	}
+
+	p := New(lexer.New("try { x }"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "expected catch or finally after try block" {
+		t.Errorf("expected missing catch/finally error, got=%v", p.Errors())
+	}
+}
+
+func TestCallExpressionPosition(t *testing.T) {
+	p := New(lexer.New("add(1,\n 2)"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if exp.Token.Literal != "(" || exp.Token.Pos.String() != "1:4" {
+		t.Errorf("call token should be ( at 1:4, got=%q at %s",
+			exp.Token.Literal, exp.Token.Pos)
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	p := New(lexer.New("2.75;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral, got=%T", stmt.Expression)
+	}
+
+	if literal.Value != 2.75 {
+		t.Errorf("literal.Value not 2.75, got=%g", literal.Value)
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	p := New(lexer.New("[1, 2 * 2, 3 + 3]"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral, got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3, got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	p := New(lexer.New("myArray[1 + 1]"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression, got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	testInfixExpression(t, indexExp.Index, 1, "+", 1)
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, "{}"},
+		{`{"one": 1, "two": 2}`, "{one: 1, two: 2}"},
+		{`{1: 0 + 1, true: 10 - 8}`, "{1: (0 + 1), true: (10 - 8)}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.HashLiteral); !ok {
+			t.Fatalf("exp is not ast.HashLiteral, got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDotExpressionParsing(t *testing.T) {
+	p := New(lexer.New("user.greet(1)"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.CallExpression, got=%T", stmt.Expression)
+	}
+
+	dot, ok := call.Func.(*ast.DotExpression)
+	if !ok {
+		t.Fatalf("call.Func not *ast.DotExpression, got=%T", call.Func)
+	}
+
+	if !testIdentifier(t, dot.Left, "user") || !testIdentifier(t, dot.Name, "greet") {
+		return
+	}
+	testLiteralExpression(t, call.Args[0], 1)
+
+	p = New(lexer.New("user.name = 5"))
+	program = p.ParseProgram()
+	checkParserErrors(t, p)
+
+	assign, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.AssignExpression, got=%T", program.Statements[0])
+	}
+	if _, ok := assign.Target.(*ast.DotExpression); !ok {
+		t.Errorf("assign.Target not *ast.DotExpression, got=%T", assign.Target)
+	}
+
+	p = New(lexer.New("user.5"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for a non-identifier member")
+	}
+}
+
+func TestImportStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		name     string
+		expected string
+	}{
+		{`import "lib"`, "lib", "lib", `import "lib";`},
+		{`import "path/to/lib";`, "path/to/lib", "lib", `import "path/to/lib";`},
+		{`import "path/to/util.mk"`, "path/to/util.mk", "util", `import "path/to/util.mk";`},
+		{`import "lib" as other`, "lib", "other", `import "lib" as other;`},
+		{`import "my-lib" as mine`, "my-lib", "mine", `import "my-lib" as mine;`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ImportStatement, got=%T", program.Statements[0])
+		}
+
+		if stmt.Path != tt.path {
+			t.Errorf("stmt.Path not %q, got=%q", tt.path, stmt.Path)
+		}
+		if !testIdentifier(t, stmt.Name, tt.name) {
+			continue
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong, expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+
+	p := New(lexer.New(`import "my-lib"`))
+	p.ParseProgram()
+	if len(p.Errors()) != 1 || p.Errors()[0] != `cannot name module "my-lib", use import "my-lib" as name` {
+		t.Errorf("expected an error naming the module, got=%v", p.Errors())
+	}
+
+	p = New(lexer.New(`let as = 1; as`))
+	p.ParseProgram()
+	checkParserErrors(t, p)
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x: int = 5;`, `let x: int = 5;`},
+		{`const names: [string] = [];`, `const names: [string] = [];`},
+		{`let ages: {string: int} = {};`, `let ages: {string: int} = {};`},
+		{`let f: fn(int, [float]): bool = g;`, `let f: fn(int, [float]): bool = g;`},
+		{`let f: fn() = g;`, `let f: fn() = g;`},
+		{`fn(a: int, b: string): bool { true }`, `fn(a: int, b: string): bool true`},
+		{`fn(a, b: string) { a }`, `fn(a, b: string)a`},
+		{`fn(a, b) { a }`, `fn(a, b)a`},
+		{`fn(): [int] { [] }`, `fn(): [int] []`},
+		{`fn(f: fn(int): int) { f }`, `fn(f: fn(int): int)f`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New(`fn(a, b: int) { a }`))
+	fn := p.ParseProgram().Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(fn.ParamTypes) != 2 || fn.ParamTypes[0] != nil || fn.ParamTypes[1].String() != "int" {
+		t.Errorf("ParamTypes should line up with the parameters, got=%v", fn.ParamTypes)
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`let x: = 5`, "expected a type, got = instead"},
+		{`let x: [int = 5`, "expected next token to be ], got = instead"},
+		{`fn(a: 1) { a }`, "expected a type, got INT instead"},
+	}
+
+	for _, tt := range errors {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 || p.Errors()[0] != tt.expected {
+			t.Errorf("%s: expected error %q, got=%v", tt.input, tt.expected, p.Errors())
+		}
+	}
+}
@@ -8,15 +8,19 @@ const (
 	_ OperatorPrecedence = iota
 
 	PRECEDENCE_LOWEST
+	PRECEDENCE_ASSIGN
 	PRECEDENCE_EQUALS
 	PRECEDENCE_LESSGREATER
 	PRECEDENCE_SUM
 	PRECEDENCE_PRODUCT
 	PRECEDENCE_PREFIX
 	PRECEDENCE_CALL
+	PRECEDENCE_INDEX
+	PRECEDENCE_MEMBER
 )
 
 var Precedences = map[token.TokenType]OperatorPrecedence{
+	token.ASSIGN:     PRECEDENCE_ASSIGN,
 	token.EQUALS:     PRECEDENCE_EQUALS,
 	token.NOT_EQUALS: PRECEDENCE_EQUALS,
 	token.LT:         PRECEDENCE_LESSGREATER,
@@ -26,6 +30,8 @@ var Precedences = map[token.TokenType]OperatorPrecedence{
 	token.SLASH:      PRECEDENCE_PRODUCT,
 	token.ASTERISK:   PRECEDENCE_PRODUCT,
 	token.LPAREN:     PRECEDENCE_CALL,
+	token.LBRACKET:   PRECEDENCE_INDEX,
+	token.DOT:        PRECEDENCE_MEMBER,
 }
 
 func DerivePrecedence(tt token.TokenType) OperatorPrecedence {
@@ -5,6 +5,7 @@ import (
 	"monkey/ast"
 	"monkey/token"
 	"strconv"
+	"strings"
 )
 
 func (p *Parser) prefixParserNotFound(t token.TokenType) {
@@ -12,6 +13,16 @@ func (p *Parser) prefixParserNotFound(t token.TokenType) {
 		fmt.Sprintf("no prefix parser for %s has been found", t))
 }
 
+// parseIllegal reports a token the lexer could not make sense of.
+func (p *Parser) parseIllegal() ast.Expression {
+	if strings.HasPrefix(p.curToken.Literal, `"`) {
+		p.addError(fmt.Sprintf("unterminated string at %s", p.curToken.Pos))
+		return nil
+	}
+	p.prefixParserNotFound(p.curToken.Type)
+	return nil
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{
 		Token: p.curToken,
@@ -32,3 +43,24 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 		Value: val,
 	}
 }
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	val, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float: %s",
+			p.curToken.Literal, err.Error())
+		p.addError(msg)
+		return nil
+	}
+	return &ast.FloatLiteral{
+		Token: p.curToken,
+		Value: val,
+	}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+}
@@ -2,12 +2,16 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"monkey/ast"
 	"monkey/eval"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"monkey/types"
+	"strings"
 )
 
 type Repl struct {
@@ -20,21 +24,43 @@ func New(in io.Reader, out io.Writer) *Repl {
 	return &Repl{prompt: ">> ", in: in, out: out}
 }
 
+// Loop reads and evaluates lines until the input ends. A line of the form
+// :type expr prints the inferred type of expr instead.
 func (r *Repl) Loop() error {
 	scanner := bufio.NewScanner(r.in)
 	env := object.NewEnvironment()
+	typeEnv := types.NewEnv()
 
 	for {
 		fmt.Fprint(r.out, r.prompt)
 		if !scanner.Scan() {
 			return nil
 		}
-		p := parser.New(lexer.New(scanner.Text()))
-		program := p.ParseProgram()
-		if errors := p.Errors(); len(errors) != 0 {
-			r.printErrors(errors)
+
+		line := scanner.Text()
+		if expr, ok := strings.CutPrefix(line, ":type "); ok {
+			program := r.parse(expr)
+			if program != nil {
+				// the line is not evaluated, so the names it binds must not
+				// be kept
+				r.printType(typeEnv.Clone(), expr, program)
+			}
 			continue
 		}
+
+		program := r.parse(line)
+		if program == nil {
+			continue
+		}
+		// keep the types of the bindings for :type, lines outside the
+		// strict profile are still evaluated but the names they bind lose
+		// their type
+		if _, err := typeEnv.Infer(program); err != nil {
+			for _, name := range boundNames(program) {
+				typeEnv.Delete(name)
+			}
+		}
+
 		res := eval.Eval(program, env)
 		if res != nil {
 			fmt.Fprintln(r.out, res.Inspect())
@@ -42,6 +68,54 @@ func (r *Repl) Loop() error {
 	}
 }
 
+// boundNames returns the names program declares at its top level or
+// assigns to anywhere.
+func boundNames(program *ast.Program) []string {
+	var names []string
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			names = append(names, let.Name.Value)
+		}
+	}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if ident, ok := assign.Target.(*ast.Identifier); ok {
+				names = append(names, ident.Value)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func (r *Repl) parse(line string) *ast.Program {
+	p := parser.New(lexer.New(line))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		r.printErrors(errors)
+		return nil
+	}
+	return program
+}
+
+// printType prints the type of program, or the type error with the span
+// it refers to underlined.
+func (r *Repl) printType(env *types.Env, line string, program *ast.Program) {
+	typ, err := env.Infer(program)
+	if err == nil {
+		fmt.Fprintln(r.out, typ)
+		return
+	}
+
+	fmt.Fprintf(r.out, "\t%s\n", err)
+	var typeErr *types.Error
+	if errors.As(err, &typeErr) && typeErr.Span.Start.Line == 1 && typeErr.Span.End.Line == 1 {
+		start, end := typeErr.Span.Start.Column, typeErr.Span.End.Column
+		fmt.Fprintf(r.out, "\t%s\n\t%s%s\n", line,
+			strings.Repeat(" ", start-1), strings.Repeat("^", max(end-start, 1)))
+	}
+}
+
 func (r *Repl) printErrors(errors []string) {
 	for _, err := range errors {
 		fmt.Fprintf(r.out, "\t%s\n", err)
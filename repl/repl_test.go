@@ -0,0 +1,59 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+)
+
+// run feeds lines to a REPL and returns the output of each, without the
+// prompts.
+func run(t *testing.T, lines ...string) []string {
+	t.Helper()
+
+	var out strings.Builder
+	r := New(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out)
+	if err := r.Loop(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	outputs := strings.Split(out.String(), r.prompt)
+	// the output starts with a prompt and ends with one when input ends
+	return outputs[1 : len(outputs)-1]
+}
+
+func TestEval(t *testing.T) {
+	outputs := run(t, "let x = 2", "x * 3", "y", "let = 1")
+
+	if outputs[1] != "6\n" {
+		t.Errorf("wrong result, got=%q", outputs[1])
+	}
+	if !strings.HasPrefix(outputs[2], "ERROR: identifier not found: y\n") {
+		t.Errorf("wrong error, got=%q", outputs[2])
+	}
+	if !strings.HasPrefix(outputs[3], "\texpected next token to be IDENT") {
+		t.Errorf("wrong parse error, got=%q", outputs[3])
+	}
+}
+
+func TestType(t *testing.T) {
+	tests := []struct {
+		lines    []string
+		expected string
+	}{
+		{[]string{`:type 1 + 2`}, "int\n"},
+		{[]string{`let id = fn(x) { x }`, `:type id`}, "fn(a): a\n"},
+		{[]string{`let n = 1`, `:type n + "a"`}, "\t1:1: cannot unify int with string\n\tn + \"a\"\n\t^^^^^^^\n"},
+		// a binding that cannot be inferred forgets the type of the name
+		{[]string{`let f = fn(x) { x + 1 }`, `let f = fn(x) { x.y }`, `:type f`}, "\t1:1: undefined: f\n\tf\n\t^\n"},
+		{[]string{`let f = fn(x) { x + 1 }`, `f = fn(x) { x.y }`, `:type f`}, "\t1:1: undefined: f\n\tf\n\t^\n"},
+		// :type does not bind anything
+		{[]string{`:type let z = 3`, `:type z`}, "\t1:1: undefined: z\n\tz\n\t^\n"},
+	}
+
+	for _, tt := range tests {
+		outputs := run(t, tt.lines...)
+		if got := outputs[len(outputs)-1]; got != tt.expected {
+			t.Errorf("%q: wrong output, want=%q, got=%q", tt.lines, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package std
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"monkey/object"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fsModule is what scripts get unless the host grants file system access
+// with NewFS: every function fails with a permission error.
+var fsModule = NewFS(nil)
+
+// WritableFS is a file system the fs module may also modify.
+type WritableFS interface {
+	fs.FS
+	// WriteFile creates or truncates the file name, or appends to it
+	WriteFile(name string, data []byte, append bool) error
+	Remove(name string) error
+}
+
+// DirFS gives access to the files below dir. Paths are resolved within
+// dir, symbolic links included, so links cannot lead out of it.
+func DirFS(dir string) WritableFS {
+	return dirFS{dir: dir}
+}
+
+type dirFS struct {
+	dir string
+}
+
+// inRoot calls fn with dir opened as the root paths are confined to.
+// Files fn opens stay usable once the root is closed.
+func (d dirFS) inRoot(fn func(root *os.Root) error) error {
+	root, err := os.OpenRoot(d.dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return fn(root)
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	var f fs.File
+	err := d.inRoot(func(root *os.Root) error {
+		var err error
+		f, err = root.FS().Open(name)
+		return err
+	})
+	return f, err
+}
+
+func (d dirFS) WriteFile(name string, data []byte, append bool) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return d.inRoot(func(root *os.Root) error {
+		f, err := root.OpenFile(filepath.FromSlash(name), flag, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+func (d dirFS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	return d.inRoot(func(root *os.Root) error {
+		return root.Remove(filepath.FromSlash(name))
+	})
+}
+
+// NewFS creates an fs module working on fsys. Paths are slash separated
+// and relative to the root of fsys; paths leaving it are refused with a
+// permission error. Writing needs fsys to be a WritableFS, a nil fsys
+// denies all access.
+func NewFS(fsys fs.FS) *object.Module {
+	f := &fileSystem{fsys: fsys}
+	return withAllocating(newModule("fs", map[string]object.BuiltinFunction{
+		"write":  f.write,
+		"append": f.append,
+		"list":   f.list,
+		"exists": f.exists,
+		"remove": f.remove,
+	}), map[string]object.AllocatingFunction{
+		"read": f.read,
+	})
+}
+
+type fileSystem struct {
+	fsys fs.FS
+}
+
+func permissionError(fn, format string, args ...any) *object.Error {
+	err := object.FormatError("permission denied: fs."+fn+": "+format, args...)
+	err.Kind = object.ERR_PERMISSION
+	return err
+}
+
+// open checks that fn may access the path in args[0], returning it
+// cleaned.
+func (f *fileSystem) open(fn string, args []object.Object, types ...object.ObjectType) (string, *object.Error) {
+	if err := checkArgs(args, len(types), types...); err != nil {
+		return "", err
+	}
+	if f.fsys == nil {
+		return "", permissionError(fn, "file system access is not enabled")
+	}
+
+	name := path.Clean(args[0].(*object.String).Value)
+	if !fs.ValidPath(name) {
+		return "", permissionError(fn, "%s is outside the root", args[0].(*object.String).Value)
+	}
+	return name, nil
+}
+
+// writable is like open but also checks that the file system is writable.
+func (f *fileSystem) writable(fn string, args []object.Object, types ...object.ObjectType) (WritableFS, string, *object.Error) {
+	name, err := f.open(fn, args, types...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	wfs, ok := f.fsys.(WritableFS)
+	if !ok {
+		return nil, "", permissionError(fn, "file system is read-only")
+	}
+	return wfs, name, nil
+}
+
+// readChunk is how much read takes from a file at a time.
+const readChunk = 32 << 10
+
+// read charges the size a file reports before reading it, and whatever
+// more it turns out to hold as that arrives.
+func (f *fileSystem) read(alloc object.Allocator, args ...object.Object) object.Object {
+	name, err := f.open("read", args, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	file, oerr := f.fsys.Open(name)
+	if oerr != nil {
+		return object.FormatError("%s", oerr)
+	}
+	defer file.Close()
+	info, serr := file.Stat()
+	if serr != nil {
+		return object.FormatError("%s", serr)
+	}
+
+	charged := max(info.Size(), 0)
+	if err := alloc(object.StringSize(0) + charged); err != nil {
+		return err
+	}
+	var data strings.Builder
+	data.Grow(int(charged))
+	chunk := make([]byte, readChunk)
+	for {
+		n, rerr := file.Read(chunk)
+		if extra := int64(data.Len()+n) - charged; extra > 0 {
+			if err := alloc(extra); err != nil {
+				return err
+			}
+			charged += extra
+		}
+		data.Write(chunk[:n])
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return object.FormatError("%s", rerr)
+		}
+	}
+	return &object.String{Value: data.String()}
+}
+
+func (f *fileSystem) write(args ...object.Object) object.Object {
+	return f.writeFile("write", args, false)
+}
+
+func (f *fileSystem) append(args ...object.Object) object.Object {
+	return f.writeFile("append", args, true)
+}
+
+func (f *fileSystem) writeFile(fn string, args []object.Object, append bool) object.Object {
+	wfs, name, err := f.writable(fn, args, object.OBJ_STRING, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	if werr := wfs.WriteFile(name, []byte(args[1].(*object.String).Value), append); werr != nil {
+		return object.FormatError("%s", werr)
+	}
+	return object.NULL
+}
+
+// list returns the sorted names in a directory, the root by default.
+func (f *fileSystem) list(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		args = []object.Object{&object.String{Value: "."}}
+	}
+	name, err := f.open("list", args, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	entries, rerr := fs.ReadDir(f.fsys, name)
+	if rerr != nil {
+		return object.FormatError("%s", rerr)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return strArray(names)
+}
+
+func (f *fileSystem) exists(args ...object.Object) object.Object {
+	name, err := f.open("exists", args, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	_, serr := fs.Stat(f.fsys, name)
+	if errors.Is(serr, fs.ErrNotExist) {
+		return object.FALSE
+	}
+	if serr != nil {
+		return object.FormatError("%s", serr)
+	}
+	return object.TRUE
+}
+
+func (f *fileSystem) remove(args ...object.Object) object.Object {
+	wfs, name, err := f.writable("remove", args, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return permissionError("remove", "cannot remove the root")
+	}
+
+	if rerr := wfs.Remove(name); rerr != nil {
+		return object.FormatError("%s", rerr)
+	}
+	return object.NULL
+}
@@ -0,0 +1,171 @@
+package std_test
+
+import (
+	"context"
+	"io/fs"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/std"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func testEvalFS(t *testing.T, input string, fsys fs.FS) object.Object {
+	t.Helper()
+	return testEvalImports(t, `import "fs"; `+input, map[string]*object.Module{"fs": std.NewFS(fsys)})
+}
+
+func TestFSDenied(t *testing.T) {
+	tests := []string{
+		`fs.read("config.json")`,
+		`fs.write("out.txt", "x")`,
+		`fs.list()`,
+		`fs.exists("/etc/passwd")`,
+	}
+
+	for _, input := range tests {
+		err, ok := testEval(t, `import "fs"; `+input).(*object.Error)
+		if !ok || err.Kind != object.ERR_PERMISSION {
+			t.Errorf("%s: expected a permission error, got=%v", input, err)
+		}
+	}
+
+	testObject(t, "denied", testEval(t, `import "fs"; fs.read("x")`),
+		"permission denied: fs.read: file system access is not enabled")
+	testObject(t, "caught", testEval(t, `import "fs"; try { fs.read("x") } catch (e) { e }`),
+		"permission denied: fs.read: file system access is not enabled")
+}
+
+func TestFSReadOnly(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.json":    {Data: []byte(`{"debug": true}`)},
+		"reports/a.txt":  {Data: []byte("a")},
+		"reports/b.txt":  {Data: []byte("b")},
+		"reports/c/x.md": {Data: []byte("x")},
+	}
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`fs.read("config.json")`, `{"debug": true}`},
+		{`fs.read("./reports/../config.json")`, `{"debug": true}`},
+		{`fs.read("missing.txt")`, "open missing.txt: file does not exist"},
+		{`fs.list()`, []any{"config.json", "reports"}},
+		{`fs.list("reports")`, []any{"a.txt", "b.txt", "c"}},
+		{`fs.exists("reports/a.txt")`, true},
+		{`fs.exists("reports/z.txt")`, false},
+		{`fs.read("../secret")`, "permission denied: fs.read: ../secret is outside the root"},
+		{`fs.read("/etc/passwd")`, "permission denied: fs.read: /etc/passwd is outside the root"},
+		{`fs.write("out.txt", "x")`, "permission denied: fs.write: file system is read-only"},
+		{`fs.remove("config.json")`, "permission denied: fs.remove: file system is read-only"},
+		{`fs.read(1)`, "argument 1: want STRING, got INTEGER"},
+		{`fs.write("out.txt")`, "wrong number of arguments: want=2, got=1"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEvalFS(t, tt.input, fsys), tt.expected)
+	}
+}
+
+func TestFSDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := std.DirFS(dir)
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`fs.read("in.txt")`, "hello"},
+		{`fs.write("out.txt", "a"); fs.append("out.txt", "b"); fs.read("out.txt")`, "ab"},
+		{`fs.write("out.txt", "c"); fs.read("out.txt")`, "c"},
+		{`fs.list()`, []any{"in.txt", "out.txt"}},
+		{`fs.remove("out.txt"); fs.exists("out.txt")`, false},
+		{`fs.remove(".")`, "permission denied: fs.remove: cannot remove the root"},
+		{`fs.write("../escape.txt", "x")`, "permission denied: fs.write: ../escape.txt is outside the root"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEvalFS(t, tt.input, fsys), tt.expected)
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); err == nil {
+		t.Errorf("fs.write escaped the root directory")
+	}
+}
+
+func TestFSReadMemory(t *testing.T) {
+	fsys := fstest.MapFS{"big.txt": {Data: make([]byte, 100000)}}
+	program := parser.New(lexer.New(`import "fs"; fs.read("big.txt")`)).ParseProgram()
+
+	e := eval.New(context.Background(), eval.Limits{})
+	e.Imports = map[string]*object.Module{"fs": std.NewFS(fsys)}
+	e.Eval(program, object.NewEnvironment())
+	// the name and the contents
+	if expected := int64((16 + 7) + (16 + 100000)); e.PeakMemory() != expected {
+		t.Errorf("expected %d bytes, got=%d", expected, e.PeakMemory())
+	}
+
+	e = eval.New(context.Background(), eval.Limits{MaxMemory: 1 << 16})
+	e.Imports = map[string]*object.Module{"fs": std.NewFS(fsys)}
+	result := e.Eval(program, object.NewEnvironment())
+	if err, ok := result.(*object.Error); !ok || err.Kind != object.ERR_MEMORY_LIMIT || e.PeakMemory() > 1<<16 {
+		t.Errorf("expected the memory limit to be exceeded, got=%s (%d bytes)", inspect(result), e.PeakMemory())
+	}
+}
+
+func TestFSDirSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "out")); err != nil {
+		t.Skipf("cannot create symbolic links: %s", err)
+	}
+	if err := os.Symlink(secret, filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("in.txt", filepath.Join(dir, "alias.txt")); err != nil {
+		t.Fatal(err)
+	}
+	fsys := std.DirFS(dir)
+
+	// links within the root still work
+	testObject(t, "alias", testEvalFS(t, `fs.read("alias.txt")`, fsys), "hello")
+
+	tests := []string{
+		`fs.read("link.txt")`,
+		`fs.read("out/secret.txt")`,
+		`fs.write("link.txt", "x")`,
+		`fs.append("out/secret.txt", "x")`,
+		`fs.write("out/new.txt", "x")`,
+		`fs.remove("out/secret.txt")`,
+		`fs.list("out")`,
+	}
+	for _, input := range tests {
+		if _, ok := testEvalFS(t, input, fsys).(*object.Error); !ok {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+
+	data, err := os.ReadFile(secret)
+	if err != nil || string(data) != "secret" {
+		t.Errorf("a file outside the root was modified, got=%q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Errorf("a file was created outside the root")
+	}
+}
@@ -0,0 +1,568 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"monkey/object"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var jsonModule = withAllocating(newModule("json", nil), map[string]object.AllocatingFunction{
+	"parse":     parseJSON,
+	"stringify": stringifyJSON,
+})
+
+// parseJSON decodes a JSON document into hashes, arrays, strings, integers,
+// floats, booleans and null. Object keys keep their order; numbers without
+// a fraction or exponent that fit become integers.
+func parseJSON(alloc object.Allocator, args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	p := &jsonParser{src: args[0].(*object.String).Value, alloc: alloc}
+	val := p.parseValue()
+	if p.err == nil {
+		p.skipSpace()
+		if p.pos < len(p.src) {
+			p.fail("unexpected %s after JSON value", p.describe())
+		}
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+	return val
+}
+
+// maxJSONDepth bounds the nesting of arrays and objects parseJSON accepts,
+// like encoding/json does, so deep documents fail instead of exhausting the
+// Go stack.
+const maxJSONDepth = 10000
+
+// jsonParser is a recursive descent parser over src. The first error stops
+// parsing and records where in src it happened. The objects it creates are
+// charged to alloc.
+type jsonParser struct {
+	src   string
+	pos   int
+	err   *object.Error
+	alloc object.Allocator
+	// depth is the number of arrays and objects being parsed
+	depth int
+}
+
+func (p *jsonParser) fail(format string, args ...any) object.Object {
+	if p.err == nil {
+		line := strings.Count(p.src[:p.pos], "\n") + 1
+		column := p.pos - strings.LastIndexByte(p.src[:p.pos], '\n')
+		p.err = object.FormatError("invalid JSON at %d:%d: %s",
+			line, column, fmt.Sprintf(format, args...))
+	}
+	return nil
+}
+
+// charge accounts for size bytes about to be allocated, stopping parsing
+// once the budget is exceeded.
+func (p *jsonParser) charge(size int64) bool {
+	if err := p.alloc(size); err != nil {
+		if p.err == nil {
+			p.err = err
+		}
+		return false
+	}
+	return true
+}
+
+// describe names the character at pos for error messages.
+func (p *jsonParser) describe() string {
+	if p.pos >= len(p.src) {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return fmt.Sprintf("character %q", r)
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// expect consumes c after optional whitespace.
+func (p *jsonParser) expect(c byte, want string) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	p.fail("unexpected %s, want %s", p.describe(), want)
+	return false
+}
+
+func (p *jsonParser) parseValue() object.Object {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return p.fail("unexpected end of input, want a value")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '{' || c == '[':
+		if p.depth == maxJSONDepth {
+			return p.fail("nesting too deep")
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if c == '{' {
+			return p.parseObject()
+		}
+		return p.parseArray()
+	case c == '"':
+		if s, ok := p.parseString(); ok && p.charge(object.StringSize(len(s))) {
+			return &object.String{Value: s}
+		}
+		return nil
+	case c == '-' || c >= '0' && c <= '9':
+		if num := p.parseNumber(); num != nil && p.charge(object.SizeOf(num)) {
+			return num
+		}
+		return nil
+	case c == 't':
+		return p.parseLiteral("true", object.TRUE)
+	case c == 'f':
+		return p.parseLiteral("false", object.FALSE)
+	case c == 'n':
+		return p.parseLiteral("null", object.NULL)
+	default:
+		return p.fail("unexpected %s, want a value", p.describe())
+	}
+}
+
+func (p *jsonParser) parseLiteral(literal string, val object.Object) object.Object {
+	rest := p.src[p.pos:]
+	if strings.HasPrefix(rest, literal) {
+		p.pos += len(literal)
+		return val
+	}
+
+	// point at the first character that does not match
+	for i := 0; i < len(rest) && rest[i] == literal[i]; i++ {
+		p.pos++
+	}
+	return p.fail("unexpected %s in literal %s", p.describe(), literal)
+}
+
+func (p *jsonParser) parseArray() object.Object {
+	p.pos++ // [
+	if !p.charge(object.ArraySize(0)) {
+		return nil
+	}
+	elements := []object.Object{}
+
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == ']' {
+		p.pos++
+		return &object.Array{Elements: elements}
+	}
+
+	for {
+		el := p.parseValue()
+		if p.err != nil || !p.charge(object.ArraySize(len(elements)+1)-object.ArraySize(len(elements))) {
+			return nil
+		}
+		elements = append(elements, el)
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ']' {
+			p.pos++
+			return &object.Array{Elements: elements}
+		}
+		if !p.expect(',', "',' or ']'") {
+			return nil
+		}
+	}
+}
+
+func (p *jsonParser) parseObject() object.Object {
+	p.pos++ // {
+	if !p.charge(object.HashSize(0)) {
+		return nil
+	}
+	hash := object.NewHash()
+
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '}' {
+		p.pos++
+		return hash
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			return p.fail("unexpected %s, want a string key", p.describe())
+		}
+		key, ok := p.parseString()
+		if !ok || !p.expect(':', "':'") {
+			return nil
+		}
+
+		val := p.parseValue()
+		if p.err != nil || !p.charge(object.StringSize(len(key))+object.HashSize(hash.Len()+1)-object.HashSize(hash.Len())) {
+			return nil
+		}
+		hash.Set(&object.String{Value: key}, val)
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '}' {
+			p.pos++
+			return hash
+		}
+		if !p.expect(',', "',' or '}'") {
+			return nil
+		}
+	}
+}
+
+var jsonEscapes = map[byte]byte{
+	'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t',
+}
+
+func (p *jsonParser) parseString() (string, bool) {
+	p.pos++ // opening quote
+	var out strings.Builder
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return out.String(), true
+		case c < 0x20:
+			p.fail("control character %q in string", c)
+			return "", false
+		case c != '\\':
+			out.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++ // backslash
+		if p.pos >= len(p.src) {
+			break
+		}
+		if esc, ok := jsonEscapes[p.src[p.pos]]; ok {
+			out.WriteByte(esc)
+			p.pos++
+			continue
+		}
+		if p.src[p.pos] != 'u' {
+			p.pos--
+			p.fail("invalid escape \\%c in string", p.src[p.pos+1])
+			return "", false
+		}
+
+		r, ok := p.parseUnicodeEscape()
+		if !ok {
+			return "", false
+		}
+		if utf16.IsSurrogate(r) && strings.HasPrefix(p.src[p.pos:], "\\u") {
+			p.pos++
+			low, ok := p.parseUnicodeEscape()
+			if !ok {
+				return "", false
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		out.WriteRune(r)
+	}
+
+	p.fail("unexpected end of input in string")
+	return "", false
+}
+
+// parseUnicodeEscape reads the hex digits of a \u escape, starting at the u.
+func (p *jsonParser) parseUnicodeEscape() (rune, bool) {
+	start := p.pos - 1
+	if p.pos+5 > len(p.src) {
+		p.pos = len(p.src)
+		p.fail("unexpected end of input in string")
+		return 0, false
+	}
+
+	r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 16)
+	if err != nil {
+		p.pos = start
+		p.fail("invalid escape %s in string", p.src[start:start+6])
+		return 0, false
+	}
+	p.pos += 5
+	return rune(r), true
+}
+
+func (p *jsonParser) parseNumber() object.Object {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	want := func(what string) object.Object {
+		return p.fail("unexpected %s in number, want %s", p.describe(), what)
+	}
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '0' {
+		p.pos++
+	} else if digits() == 0 {
+		return want("a digit")
+	}
+
+	integer := true
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		integer = false
+		p.pos++
+		if digits() == 0 {
+			return want("a digit")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		integer = false
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return want("a digit")
+		}
+	}
+
+	literal := p.src[start:p.pos]
+	if integer {
+		if i, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return object.AsInt(i)
+		}
+	}
+
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		p.pos = start
+		return p.fail("number %s out of range", literal)
+	}
+	return &object.Float{Value: f}
+}
+
+// maxIndent bounds the indent of stringifyJSON, which is repeated once per
+// level of nesting on every line.
+const maxIndent = 16
+
+// stringifyJSON encodes a value as JSON. The optional indent is a number
+// of spaces or a string of at most maxIndent bytes; without it the output
+// is compact.
+func stringifyJSON(alloc object.Allocator, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.FormatError("wrong number of arguments: want 1 to 2, got=%d", len(args))
+	}
+
+	enc := &jsonEncoder{seen: make(map[object.Object]bool), alloc: alloc}
+	if len(args) == 2 {
+		switch indent := args[1].(type) {
+		case *object.Integer:
+			if indent.Value < 0 || indent.Value > maxIndent {
+				return argError(1, "indent %d out of range [0, %d]", indent.Value, maxIndent)
+			}
+			enc.indent = strings.Repeat(" ", int(indent.Value))
+		case *object.String:
+			if len(indent.Value) > maxIndent {
+				return argError(1, "indent of %d bytes longer than %d", len(indent.Value), maxIndent)
+			}
+			enc.indent = indent.Value
+		default:
+			return argError(1, "want INTEGER or STRING, got %s", indent.Type())
+		}
+	}
+
+	err := enc.charge(int(object.StringSize(0)))
+	if err == nil {
+		err = enc.encode(args[0], 0)
+	}
+	if enc.exceeded != nil {
+		return enc.exceeded
+	}
+	if err != nil {
+		return object.FormatError("cannot stringify %s", err)
+	}
+	return &object.String{Value: enc.buf.String()}
+}
+
+// errBudget stops encoding once the output exceeds the memory budget.
+var errBudget = errors.New("memory limit exceeded")
+
+// jsonEncoder writes JSON to buf, charging every piece of output to alloc
+// before writing it.
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	indent string
+	// seen holds the arrays and hashes being encoded, to detect cycles
+	seen  map[object.Object]bool
+	alloc object.Allocator
+	// exceeded is the error of alloc once the budget is exceeded
+	exceeded *object.Error
+	// str holds encoded strings until they are charged
+	str bytes.Buffer
+}
+
+func (e *jsonEncoder) charge(n int) error {
+	if err := e.alloc(int64(n)); err != nil {
+		e.exceeded = err
+		return errBudget
+	}
+	return nil
+}
+
+func (e *jsonEncoder) write(s string) error {
+	if err := e.charge(len(s)); err != nil {
+		return err
+	}
+	e.buf.WriteString(s)
+	return nil
+}
+
+func (e *jsonEncoder) encode(obj object.Object, depth int) error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return e.write("null")
+	case *object.Boolean:
+		return e.write(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		return e.write(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return fmt.Errorf("%s", obj.Inspect())
+		}
+		return e.write(obj.Inspect())
+	case *object.String:
+		return e.encodeString(obj.Value)
+
+	case *object.Array:
+		if e.seen[obj] {
+			return fmt.Errorf("cyclic %s", obj.Type())
+		}
+		e.seen[obj] = true
+		defer delete(e.seen, obj)
+
+		if err := e.write("["); err != nil {
+			return err
+		}
+		for i, el := range obj.Elements {
+			if err := e.separate(i, depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		if err := e.close(len(obj.Elements), depth); err != nil {
+			return err
+		}
+		return e.write("]")
+
+	case *object.Hash:
+		if e.seen[obj] {
+			return fmt.Errorf("cyclic %s", obj.Type())
+		}
+		e.seen[obj] = true
+		defer delete(e.seen, obj)
+
+		if err := e.write("{"); err != nil {
+			return err
+		}
+		colon := ":"
+		if e.indent != "" {
+			colon = ": "
+		}
+		for i, pair := range obj.Pairs() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return fmt.Errorf("hash key %s: want STRING, got %s",
+					pair.Key.Inspect(), pair.Key.Type())
+			}
+
+			if err := e.separate(i, depth+1); err != nil {
+				return err
+			}
+			if err := e.encodeString(key.Value); err != nil {
+				return err
+			}
+			if err := e.write(colon); err != nil {
+				return err
+			}
+			if err := e.encode(pair.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		if err := e.close(obj.Len(), depth); err != nil {
+			return err
+		}
+		return e.write("}")
+
+	default:
+		return fmt.Errorf("%s", obj.Type())
+	}
+}
+
+func (e *jsonEncoder) encodeString(s string) error {
+	e.str.Reset()
+	enc := json.NewEncoder(&e.str)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode terminates the value with a newline
+	e.str.Truncate(e.str.Len() - 1)
+	if err := e.charge(e.str.Len()); err != nil {
+		return err
+	}
+	e.buf.Write(e.str.Bytes())
+	return nil
+}
+
+// separate starts the i-th element of a container.
+func (e *jsonEncoder) separate(i, depth int) error {
+	if i > 0 {
+		if err := e.write(","); err != nil {
+			return err
+		}
+	}
+	return e.newline(depth)
+}
+
+// close ends a container of n elements before its closing delimiter.
+func (e *jsonEncoder) close(n, depth int) error {
+	if n > 0 {
+		return e.newline(depth)
+	}
+	return nil
+}
+
+func (e *jsonEncoder) newline(depth int) error {
+	if e.indent == "" {
+		return nil
+	}
+	if err := e.charge(1 + len(e.indent)*depth); err != nil {
+		return err
+	}
+	e.buf.WriteByte('\n')
+	for range depth {
+		e.buf.WriteString(e.indent)
+	}
+	return nil
+}
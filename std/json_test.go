@@ -0,0 +1,140 @@
+package std_test
+
+import (
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`json.parse("1")`, 1},
+		{`json.parse("-0")`, 0},
+		{`json.parse("-1.5e2")`, -150.0},
+		{`json.parse("1.0")`, 1.0},
+		{`json.parse("123456789012345678901234567890")`, 1.2345678901234568e+29},
+		{`json.parse("\"a\\u00e9\\n\\/\"")`, "aé\n/"},
+		{`json.parse("\"\\ud83d\\ude00\"")`, "😀"},
+		{`json.parse("true")`, true},
+		{`json.parse("null")`, nil},
+		{`json.parse(" [1, \"two\", [[]]] ")`, []any{1, "two", []any{[]any{}}}},
+		{`json.parse("{\"b\": 1, \"a\": [true, null]}")["a"]`, []any{true, nil}},
+		{`json.parse("{\"a\": 1, \"a\": 2}")["a"]`, 2},
+		{`json.parse("")`, "invalid JSON at 1:1: unexpected end of input, want a value"},
+		{`json.parse("[1, 2")`, "invalid JSON at 1:6: unexpected end of input, want ',' or ']'"},
+		{`json.parse("{\"a\": }")`, "invalid JSON at 1:7: unexpected character '}', want a value"},
+		{`json.parse("[1,\n 2 3]")`, "invalid JSON at 2:4: unexpected character '3', want ',' or ']'"},
+		{`json.parse("{1: 2}")`, "invalid JSON at 1:2: unexpected character '1', want a string key"},
+		{`json.parse("{\"a\" 2}")`, "invalid JSON at 1:6: unexpected character '2', want ':'"},
+		{`json.parse("{\"a\": 1,}")`, "invalid JSON at 1:9: unexpected character '}', want a string key"},
+		{`json.parse("1 2")`, "invalid JSON at 1:3: unexpected character '2' after JSON value"},
+		{`json.parse("tru")`, "invalid JSON at 1:4: unexpected end of input in literal true"},
+		{`json.parse("nul!")`, "invalid JSON at 1:4: unexpected character '!' in literal null"},
+		{`json.parse("01")`, "invalid JSON at 1:2: unexpected character '1' after JSON value"},
+		{`json.parse("1.")`, "invalid JSON at 1:3: unexpected end of input in number, want a digit"},
+		{`json.parse("-x")`, "invalid JSON at 1:2: unexpected character 'x' in number, want a digit"},
+		{`json.parse("1e999")`, "invalid JSON at 1:1: number 1e999 out of range"},
+		{`json.parse("\"ab")`, "invalid JSON at 1:4: unexpected end of input in string"},
+		{`json.parse("\"a\\x\"")`, "invalid JSON at 1:3: invalid escape \\x in string"},
+		{`json.parse("\"\\u12g4\"")`, "invalid JSON at 1:2: invalid escape \\u12g4 in string"},
+		{`json.parse("\"a\tb\"")`, "invalid JSON at 1:3: control character '\\t' in string"},
+		{`json.parse("[1, é]")`, "invalid JSON at 1:5: unexpected character 'é', want a value"},
+		{`json.parse(1)`, "argument 1: want STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(t, `import "json"; `+tt.input), tt.expected)
+	}
+
+	hash, ok := testEval(t, `import "json"; json.parse("{\"b\": 1, \"a\": {\"c\": null}, \"d\": {}}")`).(*object.Hash)
+	if !ok {
+		t.Fatalf("expected a hash")
+	}
+	if hash.Inspect() != `{b: 1, a: {c: null}, d: {}}` {
+		t.Errorf("hash should keep key order, got=%s", hash.Inspect())
+	}
+}
+
+func TestJSONParseDepth(t *testing.T) {
+	tests := []struct {
+		doc      string
+		expected any
+	}{
+		{strings.Repeat("[", 10000) + strings.Repeat("]", 10000), nil},
+		{strings.Repeat(`{"a":`, 10000) + "1" + strings.Repeat("}", 10000), nil},
+		{strings.Repeat("[", 10001) + strings.Repeat("]", 10001), "invalid JSON at 1:10001: nesting too deep"},
+		{strings.Repeat("[", 5_000_000), "invalid JSON at 1:10001: nesting too deep"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("doc", &object.String{Value: tt.doc})
+		result := testEvalEnv(t, `import "json"; json.parse(doc)`, env)
+
+		if tt.expected == nil {
+			if _, ok := result.(*object.Error); ok {
+				t.Errorf("%.10s...: unexpected error %s", tt.doc, result.Inspect())
+			}
+			continue
+		}
+		testObject(t, tt.doc[:10]+"...", result, tt.expected)
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`json.stringify(1)`, "1"},
+		{`json.stringify(2.0)`, "2.0"},
+		{`json.stringify(json.parse("null"))`, "null"},
+		{`json.stringify("a\"<b>\n")`, `"a\"<b>\n"`},
+		{`json.stringify([1, true, "x", []])`, `[1,true,"x",[]]`},
+		{`json.stringify({"b": 1, "a": {}})`, `{"b":1,"a":{}}`},
+		{`json.stringify({"a": [1, 2], "b": {"c": json.parse("null")}}, 2)`,
+			"{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"c\": null\n  }\n}"},
+		{`json.stringify([1], "\t")`, "[\n\t1\n]"},
+		{`json.stringify([], 2)`, "[]"},
+		{`json.stringify(json.parse("{\"x\": [1.5, \"y\"]}"))`, `{"x":[1.5,"y"]}`},
+		{`json.stringify(fn(x) { x })`, "cannot stringify FUNCTION"},
+		{`json.stringify([puts])`, "cannot stringify BUILTIN"},
+		{`json.stringify({1: "a"})`, "cannot stringify hash key 1: want STRING, got INTEGER"},
+		{`json.stringify(1, -1)`, "argument 2: indent -1 out of range [0, 16]"},
+		{`json.stringify(1, "                 ")`, "argument 2: indent of 17 bytes longer than 16"},
+		{`json.stringify(1, true)`, "argument 2: want INTEGER or STRING, got BOOLEAN"},
+		{`json.stringify()`, "wrong number of arguments: want 1 to 2, got=0"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(t, `import "json"; `+tt.input), tt.expected)
+	}
+}
+
+func TestJSONStringifyCycle(t *testing.T) {
+	arr := &object.Array{}
+	arr.Elements = []object.Object{object.AsInt(1), arr}
+	hash := object.NewHash()
+	hash.Set(&object.String{Value: "self"}, hash)
+	shared := &object.Array{Elements: []object.Object{object.AsInt(1)}}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.stringify(arr)`, "cannot stringify cyclic ARRAY"},
+		{`json.stringify(hash)`, "cannot stringify cyclic HASH"},
+		{`json.stringify([shared, shared])`, "[[1],[1]]"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("arr", arr)
+		env.Set("hash", hash)
+		env.Set("shared", shared)
+		testObject(t, tt.input, testEvalEnv(t, `import "json"; `+tt.input, env), tt.expected)
+	}
+}
@@ -0,0 +1,232 @@
+package std
+
+import (
+	"cmp"
+	"math"
+	"monkey/object"
+)
+
+// Functions of the math module return integers when all their arguments
+// are integers and the result is exact, and floats otherwise. sqrt, log,
+// exp and the trigonometric functions always return floats.
+var mathModule = func() *object.Module {
+	mod := newModule("math", map[string]object.BuiltinFunction{
+		"abs":   abs,
+		"min":   minimum,
+		"max":   maximum,
+		"clamp": clamp,
+		"pow":   pow,
+		"gcd":   gcd,
+		"sqrt":  floatFunc(math.Sqrt),
+		"log":   floatFunc(math.Log),
+		"exp":   floatFunc(math.Exp),
+		"sin":   floatFunc(math.Sin),
+		"cos":   floatFunc(math.Cos),
+		"tan":   floatFunc(math.Tan),
+		"asin":  floatFunc(math.Asin),
+		"acos":  floatFunc(math.Acos),
+		"atan":  floatFunc(math.Atan),
+		"atan2": atan2,
+	})
+	mod.Env.Declare("PI", &object.Float{Value: math.Pi}, true)
+	mod.Env.Declare("E", &object.Float{Value: math.E}, true)
+	mod.Env.Declare("random", randomModule, true)
+	return mod
+}()
+
+// numbers checks that args are numbers, returning them as floats and
+// whether all of them are integers.
+func numbers(args []object.Object) ([]float64, bool, *object.Error) {
+	floats := make([]float64, len(args))
+	ints := true
+
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *object.Integer:
+			floats[i] = float64(arg.Value)
+		case *object.Float:
+			floats[i] = arg.Value
+			ints = false
+		default:
+			return nil, false, argError(i, "want INTEGER or FLOAT, got %s", arg.Type())
+		}
+	}
+	return floats, ints, nil
+}
+
+func argCount(args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return object.FormatError("wrong number of arguments: want=%d, got=%d", want, len(args))
+	}
+	return nil
+}
+
+func intValue(obj object.Object) int64 {
+	return obj.(*object.Integer).Value
+}
+
+// floatFunc wraps a function of one float.
+func floatFunc(fn func(float64) float64) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		if err := argCount(args, 1); err != nil {
+			return err
+		}
+		x, _, err := numbers(args)
+		if err != nil {
+			return err
+		}
+		return &object.Float{Value: fn(x[0])}
+	}
+}
+
+func atan2(args ...object.Object) object.Object {
+	if err := argCount(args, 2); err != nil {
+		return err
+	}
+	yx, _, err := numbers(args)
+	if err != nil {
+		return err
+	}
+	return &object.Float{Value: math.Atan2(yx[0], yx[1])}
+}
+
+func abs(args ...object.Object) object.Object {
+	if err := argCount(args, 1); err != nil {
+		return err
+	}
+	x, ints, err := numbers(args)
+	if err != nil {
+		return err
+	}
+
+	if !ints {
+		return &object.Float{Value: math.Abs(x[0])}
+	}
+	i := intValue(args[0])
+	if i == math.MinInt64 {
+		return object.FormatError("integer overflow: abs(%d)", i)
+	}
+	if i < 0 {
+		i = -i
+	}
+	return object.AsInt(i)
+}
+
+func minimum(args ...object.Object) object.Object {
+	return extremum(args, -1)
+}
+
+func maximum(args ...object.Object) object.Object {
+	return extremum(args, 1)
+}
+
+// extremum returns the argument that compares as want, -1 for the minimum
+// and 1 for the maximum, to all others, keeping the first one on ties.
+func extremum(args []object.Object, want int) object.Object {
+	if len(args) == 0 {
+		return object.FormatError("wrong number of arguments: want at least 1, got=0")
+	}
+	x, ints, err := numbers(args)
+	if err != nil {
+		return err
+	}
+
+	best := 0
+	for i := range args {
+		c := cmp.Compare(x[i], x[best])
+		if ints {
+			c = cmp.Compare(intValue(args[i]), intValue(args[best]))
+		}
+		if c == want {
+			best = i
+		}
+	}
+
+	if ints {
+		return args[best]
+	}
+	return &object.Float{Value: x[best]}
+}
+
+func clamp(args ...object.Object) object.Object {
+	if err := argCount(args, 3); err != nil {
+		return err
+	}
+	x, ints, err := numbers(args)
+	if err != nil {
+		return err
+	}
+	if x[1] > x[2] {
+		return object.FormatError("clamp: lower bound %s above upper bound %s",
+			args[1].Inspect(), args[2].Inspect())
+	}
+
+	if ints {
+		v, lo, hi := intValue(args[0]), intValue(args[1]), intValue(args[2])
+		return object.AsInt(max(lo, min(v, hi)))
+	}
+	return &object.Float{Value: math.Max(x[1], math.Min(x[0], x[2]))}
+}
+
+// pow stays exact for integer bases and non-negative integer exponents,
+// reporting overflow instead of wrapping around.
+func pow(args ...object.Object) object.Object {
+	if err := argCount(args, 2); err != nil {
+		return err
+	}
+	x, ints, err := numbers(args)
+	if err != nil {
+		return err
+	}
+
+	if !ints || intValue(args[1]) < 0 {
+		return &object.Float{Value: math.Pow(x[0], x[1])}
+	}
+
+	base, exp := intValue(args[0]), intValue(args[1])
+	switch {
+	case base == 0 || base == 1:
+		if exp == 0 {
+			return object.AsInt(1)
+		}
+		return object.AsInt(base)
+	case base == -1:
+		if exp%2 == 0 {
+			return object.AsInt(1)
+		}
+		return object.AsInt(-1)
+	}
+
+	// any other base overflows within 63 multiplications
+	result := int64(1)
+	for ; exp > 0; exp-- {
+		next := result * base
+		if next/base != result {
+			return object.FormatError("integer overflow: pow(%d, %d)", base, intValue(args[1]))
+		}
+		result = next
+	}
+	return object.AsInt(result)
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func gcd(args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_INTEGER, object.OBJ_INTEGER); err != nil {
+		return err
+	}
+
+	a, b := abs64(intValue(args[0])), abs64(intValue(args[1]))
+	if a < 0 || b < 0 {
+		return object.FormatError("integer overflow: gcd(%d, %d)", intValue(args[0]), intValue(args[1]))
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return object.AsInt(a)
+}
@@ -0,0 +1,108 @@
+package std_test
+
+import (
+	"math"
+	"monkey/object"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`math.abs(-5)`, 5},
+		{`math.abs(-2.5)`, 2.5},
+		{`math.abs(-9223372036854775807 - 1)`, "integer overflow: abs(-9223372036854775808)"},
+		{`math.min(3, 1, 2)`, 1},
+		{`math.min(3, 1.5, 2)`, 1.5},
+		{`math.min(1, 2.0)`, 1.0},
+		{`math.max(3, 7, 2)`, 7},
+		{`math.max(-9223372036854775807, 9223372036854775807)`, 9223372036854775807},
+		{`math.max(1, 2.5)`, 2.5},
+		{`math.max()`, "wrong number of arguments: want at least 1, got=0"},
+		{`math.clamp(15, 0, 10)`, 10},
+		{`math.clamp(-1, 0, 10)`, 0},
+		{`math.clamp(5, 0, 10)`, 5},
+		{`math.clamp(0.5, 0, 1)`, 0.5},
+		{`math.clamp(2, 0, 1.5)`, 1.5},
+		{`math.clamp(1, 10, 0)`, "clamp: lower bound 10 above upper bound 0"},
+		{`math.pow(2, 10)`, 1024},
+		{`math.pow(-3, 3)`, -27},
+		{`math.pow(2, 0)`, 1},
+		{`math.pow(-1, 1000000000001)`, -1},
+		{`math.pow(1, 9223372036854775807)`, 1},
+		{`math.pow(0, 0)`, 1},
+		{`math.pow(2, -1)`, 0.5},
+		{`math.pow(4, 0.5)`, 2.0},
+		{`math.pow(2, 63)`, "integer overflow: pow(2, 63)"},
+		{`math.pow(-2, 63)`, -9223372036854775807 - 1},
+		{`math.gcd(12, 18)`, 6},
+		{`math.gcd(-12, 18)`, 6},
+		{`math.gcd(0, 5)`, 5},
+		{`math.gcd(1.5, 2)`, "argument 1: want INTEGER, got FLOAT"},
+		{`math.sqrt(16)`, 4.0},
+		{`math.sqrt(2.25)`, 1.5},
+		{`math.log(1)`, 0.0},
+		{`math.exp(0)`, 1.0},
+		{`math.sin(0)`, 0.0},
+		{`math.cos(0)`, 1.0},
+		{`math.atan2(0, 1)`, 0.0},
+		{`math.PI`, math.Pi},
+		{`math.E`, math.E},
+		{`math.sqrt("x")`, "argument 1: want INTEGER or FLOAT, got STRING"},
+		{`math.sqrt(1, 2)`, "wrong number of arguments: want=1, got=2"},
+		{`math.min(1, "x")`, "argument 2: want INTEGER or FLOAT, got STRING"},
+		{`math.PI = 3`, "member assignment not supported: MODULE.PI"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(t, `import "math"; `+tt.input), tt.expected)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	draw := `import "math/random";
+let r = random.new(42);
+[r.int(100), r.int(100), r.range(-5, 5), r.float(), r.choice(["a", "b", "c"]), r.shuffle([1, 2, 3, 4])]`
+
+	first := testEval(t, draw)
+	if object.IsError(first) {
+		t.Fatalf("unexpected error: %s", first.Inspect())
+	}
+	if second := testEval(t, draw); second.Inspect() != first.Inspect() {
+		t.Errorf("same seed should draw the same values, got=%s and %s", first.Inspect(), second.Inspect())
+	}
+
+	other := testEval(t, `import "math"; let r = math.random.new(7); [r.int(1000000), r.int(1000000)]`)
+	again := testEval(t, `import "math"; let r = math.random.new(7); [r.int(1000000), r.int(1000000)]`)
+	if other.Inspect() != again.Inspect() {
+		t.Errorf("math.random should be the random module, got=%s and %s", other.Inspect(), again.Inspect())
+	}
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`r.int(0)`, "argument 1: want a positive bound, got 0"},
+		{`r.range(3, 3)`, "empty range [3, 3)"},
+		{`r.choice([])`, "argument 1: empty array"},
+		{`r.float(1)`, "wrong number of arguments: want=0, got=1"},
+		{`random.new()`, "wrong number of arguments: want=1, got=0"},
+		{`let a = [1, 2, 3]; r.shuffle(a); a`, []any{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		input := `import "math/random"; let r = random.new(1); ` + tt.input
+		testObject(t, tt.input, testEval(t, input), tt.expected)
+	}
+
+	draws := testEval(t, `import "math/random";
+let r = random.new(3);
+[r.range(-2, 2), r.range(-2, 2), r.range(-2, 2), r.range(-2, 2), r.range(-2, 2), r.range(-2, 2)]`)
+	for _, obj := range draws.(*object.Array).Elements {
+		if n, ok := obj.(*object.Integer); !ok || n.Value < -2 || n.Value >= 2 {
+			t.Fatalf("r.range(-2, 2) out of range, got=%s", obj.Inspect())
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package std
+
+import (
+	"monkey/object"
+	"regexp"
+	"sync"
+)
+
+// Every function of the regex module takes the pattern as its first
+// argument, either a REGEX from compile or a string. Strings are compiled
+// once and cached, so literal patterns in loops stay cheap.
+var regexModule = withAllocating(newModule("regex", map[string]object.BuiltinFunction{
+	"compile": compileRegex,
+	"match":   matchRegex,
+	"find":    findRegex,
+}), map[string]object.AllocatingFunction{
+	"find_all": findAllRegex,
+	"captures": capturesRegex,
+	"replace":  replaceRegex,
+	"split":    splitRegex,
+})
+
+// maxCachedPatterns bounds the pattern cache; it is emptied once full.
+const maxCachedPatterns = 256
+
+var patternCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+func compilePattern(pattern string) (*regexp.Regexp, *object.Error) {
+	patternCache.Lock()
+	defer patternCache.Unlock()
+
+	if re, ok := patternCache.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, object.FormatError("%s", err)
+	}
+	if len(patternCache.patterns) >= maxCachedPatterns {
+		clear(patternCache.patterns)
+	}
+	patternCache.patterns[pattern] = re
+	return re, nil
+}
+
+// regexArgs checks the arguments of a regex function: the pattern, a
+// subject string and then the remaining types, optional from required on.
+// It returns the compiled pattern.
+func regexArgs(args []object.Object, required int, types ...object.ObjectType) (*regexp.Regexp, *object.Error) {
+	if len(args) == 0 {
+		return nil, checkArgs(args, required, append([]object.ObjectType{object.OBJ_STRING}, types...)...)
+	}
+
+	switch pattern := args[0].(type) {
+	case *object.Regex:
+		types = append([]object.ObjectType{object.OBJ_REGEX}, types...)
+		if err := checkArgs(args, required, types...); err != nil {
+			return nil, err
+		}
+		return pattern.Value, nil
+	case *object.String:
+		types = append([]object.ObjectType{object.OBJ_STRING}, types...)
+		if err := checkArgs(args, required, types...); err != nil {
+			return nil, err
+		}
+		return compilePattern(pattern.Value)
+	default:
+		return nil, argError(0, "want REGEX or STRING, got %s", pattern.Type())
+	}
+}
+
+// limit reads the optional maximum number of results at args[i], all of
+// them by default.
+func limit(args []object.Object, i int) int {
+	if len(args) > i {
+		return int(intValue(args[i]))
+	}
+	return -1
+}
+
+func compileRegex(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(args[0].(*object.String).Value)
+	if err != nil {
+		return object.FormatError("%s", err)
+	}
+	return &object.Regex{Value: re}
+}
+
+func matchRegex(args ...object.Object) object.Object {
+	re, err := regexArgs(args, 2, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+	return object.AsBool(re.MatchString(args[1].(*object.String).Value))
+}
+
+// findRegex returns the first match, or null.
+func findRegex(args ...object.Object) object.Object {
+	re, err := regexArgs(args, 2, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	loc := re.FindStringIndex(args[1].(*object.String).Value)
+	if loc == nil {
+		return object.NULL
+	}
+	return str(args[1].(*object.String).Value[loc[0]:loc[1]])
+}
+
+// findAllRegex returns the matches, at most the optional third argument
+// many.
+func findAllRegex(alloc object.Allocator, args ...object.Object) object.Object {
+	re, err := regexArgs(args, 2, object.OBJ_STRING, object.OBJ_INTEGER)
+	if err != nil {
+		return err
+	}
+	return allocStrArray(alloc, re.FindAllString(args[1].(*object.String).Value, limit(args, 2)))
+}
+
+// allocStrArray is strArray charging the array and its strings to alloc
+// first. The parts of strs are substrings sharing the memory of their
+// subject, so only the objects holding them are left to create.
+func allocStrArray(alloc object.Allocator, strs []string) object.Object {
+	size := object.ArraySize(len(strs))
+	for _, s := range strs {
+		size += object.StringSize(len(s))
+	}
+	if err := alloc(size); err != nil {
+		return err
+	}
+	return strArray(strs)
+}
+
+// capturesRegex returns the groups of the first match as a hash, or null.
+// Every group is keyed by its index, 0 being the whole match, and named
+// groups also by name. Groups that did not participate are null.
+func capturesRegex(alloc object.Allocator, args ...object.Object) object.Object {
+	re, err := regexArgs(args, 2, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	s := args[1].(*object.String).Value
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return object.NULL
+	}
+
+	names := re.SubexpNames()
+	pairs, size := len(names), int64(0)
+	for i, name := range names {
+		if loc[2*i] >= 0 {
+			size += object.StringSize(loc[2*i+1] - loc[2*i])
+		}
+		if name != "" {
+			pairs++
+			size += object.StringSize(len(name))
+		}
+	}
+	if err := alloc(object.HashSize(pairs) + size); err != nil {
+		return err
+	}
+
+	hash := object.NewHash()
+	for i, name := range names {
+		var group object.Object = object.NULL
+		if loc[2*i] >= 0 {
+			group = str(s[loc[2*i]:loc[2*i+1]])
+		}
+
+		hash.Set(object.AsInt(int64(i)), group)
+		if name != "" {
+			hash.Set(str(name), group)
+		}
+	}
+	return hash
+}
+
+// replaceRegex replaces every match, expanding $1 or ${name} in the
+// replacement to the groups of the match.
+func replaceRegex(alloc object.Allocator, args ...object.Object) object.Object {
+	re, err := regexArgs(args, 3, object.OBJ_STRING, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	s, template := args[1].(*object.String).Value, args[2].(*object.String).Value
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	literal, refs := templateSize(re, template)
+	size := int64(len(s))
+	for _, m := range matches {
+		size += int64(literal - (m[1] - m[0]))
+		for i, n := range refs {
+			if m[2*i] >= 0 {
+				size += int64(n) * int64(m[2*i+1]-m[2*i])
+			}
+		}
+	}
+	if err := alloc(object.StringSize(0) + size); err != nil {
+		return err
+	}
+
+	replaced := make([]byte, 0, size)
+	last := 0
+	for _, m := range matches {
+		replaced = append(replaced, s[last:m[0]]...)
+		replaced = re.ExpandString(replaced, template, s, m)
+		last = m[1]
+	}
+	return str(string(append(replaced, s[last:]...)))
+}
+
+// templateSize measures how template expands for re: every expansion is
+// literal bytes long plus, for each group i, refs[i] times the length of
+// the group. It expands template with every group empty, then with each
+// one a byte long in turn.
+func templateSize(re *regexp.Regexp, template string) (literal int, refs []int) {
+	loc := make([]int, 2*(re.NumSubexp()+1))
+	buf := re.ExpandString(nil, template, "x", loc)
+	literal = len(buf)
+
+	refs = make([]int, len(loc)/2)
+	for i := range refs {
+		loc[2*i+1] = 1
+		buf = re.ExpandString(buf[:0], template, "x", loc)
+		refs[i] = len(buf) - literal
+		loc[2*i+1] = 0
+	}
+	return literal, refs
+}
+
+// splitRegex splits around the matches into at most the optional third
+// argument many parts.
+func splitRegex(alloc object.Allocator, args ...object.Object) object.Object {
+	re, err := regexArgs(args, 2, object.OBJ_STRING, object.OBJ_INTEGER)
+	if err != nil {
+		return err
+	}
+	return allocStrArray(alloc, re.Split(args[1].(*object.String).Value, limit(args, 2)))
+}
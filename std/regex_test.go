@@ -0,0 +1,48 @@
+package std_test
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestRegex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`regex.match("^[a-z]+$", "monkey")`, true},
+		{`regex.match("^[a-z]+$", "Monkey")`, false},
+		{`regex.match(regex.compile("\\d+"), "abc123")`, true},
+		{`regex.find("\\d+", "abc 123 45")`, "123"},
+		{`regex.find("\\d+", "abc")`, nil},
+		{`regex.find_all("\\d+", "1 22 333")`, []any{"1", "22", "333"}},
+		{`regex.find_all("\\d+", "1 22 333", 2)`, []any{"1", "22"}},
+		{`regex.find_all("\\d+", "none")`, []any{}},
+		{`regex.replace("(\\w+)@(\\w+)", "ann@home", "$2:$1")`, "home:ann"},
+		{`regex.replace("(?P<user>\\w+)@", "ann@home", "${user} at ")`, "ann at home"},
+		{`regex.split(",\\s*", "a, b,c")`, []any{"a", "b", "c"}},
+		{`regex.split(",", "a,b,c", 2)`, []any{"a", "b,c"}},
+		{`let m = regex.captures("(?P<year>\\d{4})-(?P<month>\\d{2})", "on 2024-03"); [m["year"], m["month"], m[0], m[2]]`,
+			[]any{"2024", "03", "2024-03", "03"}},
+		{`regex.captures("(a)|(b)", "b")[1]`, nil},
+		{`regex.captures("x", "y")`, nil},
+		{`regex.compile("a+b").pattern`, "a+b"},
+		{`regex.compile("(a)(?P<b>b)").groups`, 2},
+		{`regex.compile("(")`, "error parsing regexp: missing closing ): `(`"},
+		{`regex.match("(", "x")`, "error parsing regexp: missing closing ): `(`"},
+		{`regex.match(1, "x")`, "argument 1: want REGEX or STRING, got INTEGER"},
+		{`regex.match("x", 1)`, "argument 2: want STRING, got INTEGER"},
+		{`regex.match("x")`, "wrong number of arguments: want=2, got=1"},
+		{`regex.split()`, "wrong number of arguments: want 2 to 3, got=0"},
+		{`regex.compile("x").flags`, "REGEX has no member flags"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(t, `import "regex"; `+tt.input), tt.expected)
+	}
+
+	re := testEval(t, `import "regex"; regex.compile("a|b")`)
+	if _, ok := re.(*object.Regex); !ok || re.Inspect() != `regex("a|b")` {
+		t.Errorf("compile should return a REGEX, got=%s (%T)", re.Inspect(), re)
+	}
+}
@@ -0,0 +1,67 @@
+// Package std implements the standard library modules scripts can import
+// without a module loader.
+package std
+
+import (
+	"fmt"
+	"monkey/object"
+)
+
+var modules = map[string]*object.Module{
+	"strings":     stringsModule,
+	"math":        mathModule,
+	"math/random": randomModule,
+	"json":        jsonModule,
+	"fs":          fsModule,
+	"time":        timeModule,
+	"regex":       regexModule,
+}
+
+// Lookup returns the standard library module imported as name.
+func Lookup(name string) (*object.Module, bool) {
+	mod, ok := modules[name]
+	return mod, ok
+}
+
+// newModule creates the module name with fns as its functions.
+func newModule(name string, fns map[string]object.BuiltinFunction) *object.Module {
+	members := make(map[string]object.Object, len(fns))
+	for fname, fn := range fns {
+		members[fname] = &object.Builtin{Name: name + "." + fname, Fn: fn}
+	}
+	return object.NewModule(name, members)
+}
+
+// withAllocating adds fns to mod as allocating builtins, which charge the
+// objects they create to the evaluation calling them.
+func withAllocating(mod *object.Module, fns map[string]object.AllocatingFunction) *object.Module {
+	for fname, fn := range fns {
+		mod.Env.Declare(fname, &object.Builtin{Name: mod.Name + "." + fname, Allocating: fn}, true)
+	}
+	return mod
+}
+
+// checkArgs reports an error unless args has the given types. Only the
+// first required arguments must be present, the rest are optional.
+func checkArgs(args []object.Object, required int, types ...object.ObjectType) *object.Error {
+	if len(args) < required || len(args) > len(types) {
+		if required == len(types) {
+			return object.FormatError("wrong number of arguments: want=%d, got=%d",
+				required, len(args))
+		}
+		return object.FormatError("wrong number of arguments: want %d to %d, got=%d",
+			required, len(types), len(args))
+	}
+
+	for i, arg := range args {
+		if arg.Type() != types[i] {
+			return argError(i, "want %s, got %s", types[i], arg.Type())
+		}
+	}
+	return nil
+}
+
+// argError reports a problem with the argument at index i.
+func argError(i int, format string, args ...any) *object.Error {
+	return object.FormatError("argument %d: %s", i+1, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,171 @@
+package std_test
+
+import (
+	"context"
+	"monkey/eval"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+	return testEvalEnv(t, input, object.NewEnvironment())
+}
+
+// testEvalImports evaluates input with the host provided modules imports.
+func testEvalImports(t *testing.T, input string, imports map[string]*object.Module) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	e := eval.New(context.Background(), eval.Limits{})
+	e.Imports = imports
+	return e.Eval(program, object.NewEnvironment())
+}
+
+// testEvalLimited evaluates input within limits, returning the result and
+// the memory accounted to it.
+func testEvalLimited(t *testing.T, input string, limits eval.Limits) (object.Object, int64) {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	e := eval.New(context.Background(), limits)
+	result := e.Eval(program, object.NewEnvironment())
+	return result, e.PeakMemory()
+}
+
+func testEvalEnv(t *testing.T, input string, env *object.Environment) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return eval.Eval(program, env)
+}
+
+// testObject compares obj to expected, an int, float64, bool, string, nil
+// for null or []any for an array. A string is also matched against the
+// message of an error.
+func testObject(t *testing.T, input string, obj object.Object, expected any) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		if i, ok := obj.(*object.Integer); !ok || i.Value != int64(expected) {
+			t.Errorf("%s: expected %d, got=%s (%T)", input, expected, inspect(obj), obj)
+		}
+	case float64:
+		if f, ok := obj.(*object.Float); !ok || f.Value != expected {
+			t.Errorf("%s: expected %g, got=%s (%T)", input, expected, inspect(obj), obj)
+		}
+	case bool:
+		if obj != object.AsBool(expected) {
+			t.Errorf("%s: expected %t, got=%s (%T)", input, expected, inspect(obj), obj)
+		}
+	case string:
+		switch obj := obj.(type) {
+		case *object.String:
+			if obj.Value != expected {
+				t.Errorf("%s: expected %q, got=%q", input, expected, obj.Value)
+			}
+		case *object.Error:
+			if obj.Msg != expected {
+				t.Errorf("%s: expected error %q, got=%q", input, expected, obj.Msg)
+			}
+		default:
+			t.Errorf("%s: expected %q, got=%s (%T)", input, expected, inspect(obj), obj)
+		}
+	case nil:
+		if obj != object.NULL {
+			t.Errorf("%s: expected null, got=%s (%T)", input, inspect(obj), obj)
+		}
+	case []any:
+		arr, ok := obj.(*object.Array)
+		if !ok || len(arr.Elements) != len(expected) {
+			t.Errorf("%s: expected %d elements, got=%s (%T)", input, len(expected), inspect(obj), obj)
+			return
+		}
+		for i, el := range expected {
+			testObject(t, input, arr.Elements[i], el)
+		}
+	default:
+		t.Fatalf("%s: cannot compare with %T", input, expected)
+	}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}
+
+func TestMemoryBudget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		// the literals, the result and the strings in it
+		{`strings.split("a,bc", ",")`, (16 + 4) + (16 + 1) + (16 + 2*8) + (16 + 1) + (16 + 2)},
+		{`strings.split("ab", "")`, (16 + 2) + (16 + 0) + (16 + 2*8) + 2*(16+1)},
+		{`strings.chars("日本")`, (16 + 6) + (16 + 2*8) + 2*(16+3)},
+		{`strings.repeat("ab", 3)`, (16 + 2) + (16 + 6)},
+		{`strings.pad_left("7", 3, "0")`, (16 + 1) + (16 + 1) + (16 + 3)},
+		{`strings.pad_right("日", 2, "本")`, (16 + 3) + (16 + 3) + (16 + 6)},
+		{`strings.replace("abab", "b", "xyz")`, (16 + 4) + (16 + 1) + (16 + 3) + (16 + 8)},
+		{`strings.replace("ab", "", "-")`, (16 + 2) + (16 + 0) + (16 + 1) + (16 + 5)},
+		{`strings.join(["a", "bc"], ", ")`, (16 + 1) + (16 + 2) + (16 + 2*8) + (16 + 2) + (16 + 5)},
+		{`regex.replace("[0-9]+", "a1b22", "<$0>")`, (16 + 6) + (16 + 5) + (16 + 4) + (16 + 9)},
+		{`regex.find_all("[a-z]+", "ab c")`, (16 + 6) + (16 + 4) + (16 + 2*8) + (16 + 2) + (16 + 1)},
+		{`regex.split(",", "a,bc")`, (16 + 1) + (16 + 4) + (16 + 2*8) + (16 + 1) + (16 + 2)},
+		// groups 0 and 1 matched, 2 did not; group 1 is also keyed by name
+		{`regex.captures("(?P<k>a)(b)?", "a")`, (16 + 12) + (16 + 1) + (16 + 4*40) + 2*(16+1) + (16 + 1)},
+		{`json.parse("[1, {\"k\": \"v\"}]")`, (16 + 15) + (16 + 2*8) + 16 + (16 + 40) + (16 + 1) + (16 + 1)},
+		// the literals, the array and hash, and 25 bytes of output
+		{`json.stringify({"a": [1, "x"]}, " ")`, (16 + 1) + (16 + 1) + (16 + 2*8) + (16 + 40) + (16 + 1) + (16 + 25)},
+	}
+
+	for _, tt := range tests {
+		input := `import "strings"; import "json"; import "regex"; ` + tt.input
+		if _, used := testEvalLimited(t, input, eval.Limits{}); used != tt.expected {
+			t.Errorf("%s: expected %d bytes, got=%d", tt.input, tt.expected, used)
+		}
+
+		result, used := testEvalLimited(t, input, eval.Limits{MaxMemory: tt.expected - 1})
+		if err, ok := result.(*object.Error); !ok || err.Kind != object.ERR_MEMORY_LIMIT {
+			t.Errorf("%s: expected the memory limit to be exceeded, got=%s", tt.input, inspect(result))
+		}
+		if used >= tt.expected {
+			t.Errorf("%s: expected to stay under the limit, got=%d", tt.input, used)
+		}
+	}
+
+	// huge strings are refused before they are built
+	for _, input := range []string{
+		`strings.repeat("ab", 500000000)`,
+		`strings.pad_left("a", 1000000000)`,
+		`let s = strings.repeat("a", 100000); strings.replace(s, "a", s)`,
+		`let s = strings.repeat("a", 10000); strings.join(map(range(10000), fn(i) { s }), "")`,
+		`let s = strings.repeat("a", 100000); regex.replace("a", s, s)`,
+		`let s = strings.repeat("a", 2000); regex.replace("a", s, strings.repeat("$0", 1000))`,
+		`let nest = fn(n, a) { if (n == 0) { a } else { nest(n - 1, [a]) } }; json.stringify(nest(9000, []), 16)`,
+	} {
+		result, used := testEvalLimited(t, `import "strings"; import "regex"; import "json"; `+input, eval.Limits{MaxMemory: 1 << 20})
+		if err, ok := result.(*object.Error); !ok || err.Kind != object.ERR_MEMORY_LIMIT || used > 1<<20 {
+			t.Errorf("%s: expected the memory limit to be exceeded, got=%s (%d bytes)", input, inspect(result), used)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package std
+
+import (
+	"monkey/object"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strings work on runes, so lengths and indices count characters rather
+// than bytes.
+var stringsModule = withAllocating(newModule("strings", map[string]object.BuiltinFunction{
+	"trim":        trim,
+	"upper":       upper,
+	"lower":       lower,
+	"contains":    contains,
+	"index":       index,
+	"starts_with": startsWith,
+	"ends_with":   endsWith,
+	"len":         length,
+}), map[string]object.AllocatingFunction{
+	"split":     split,
+	"join":      join,
+	"replace":   replace,
+	"chars":     chars,
+	"repeat":    repeat,
+	"pad_left":  padLeft,
+	"pad_right": padRight,
+})
+
+func str(s string) *object.String {
+	return &object.String{Value: s}
+}
+
+func strArray(strs []string) *object.Array {
+	elements := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elements[i] = str(s)
+	}
+	return &object.Array{Elements: elements}
+}
+
+func split(alloc object.Allocator, args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	s, sep := args[0].(*object.String).Value, args[1].(*object.String).Value
+	parts := utf8.RuneCountInString(s)
+	if sep != "" {
+		parts = strings.Count(s, sep) + 1
+	}
+	// the parts hold every byte of s but the separators
+	size := object.ArraySize(parts) + int64(parts)*object.StringSize(0) + int64(len(s)-(parts-1)*len(sep))
+	if err := alloc(size); err != nil {
+		return err
+	}
+	return strArray(strings.Split(s, sep))
+}
+
+func join(alloc object.Allocator, args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_ARRAY, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	elements, sep := args[0].(*object.Array).Elements, args[1].(*object.String).Value
+	strs := make([]string, len(elements))
+	size := object.StringSize(0)
+	for i, el := range elements {
+		s, ok := el.(*object.String)
+		if !ok {
+			return argError(0, "element %d is %s, want STRING", i, el.Type())
+		}
+		strs[i] = s.Value
+		size += int64(len(s.Value))
+	}
+	if len(strs) > 1 {
+		size += int64(len(strs)-1) * int64(len(sep))
+	}
+	if err := alloc(size); err != nil {
+		return err
+	}
+	return str(strings.Join(strs, sep))
+}
+
+// trim removes leading and trailing whitespace, or the characters in the
+// optional second argument.
+func trim(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	if len(args) == 2 {
+		return str(strings.Trim(s, args[1].(*object.String).Value))
+	}
+	return str(strings.TrimSpace(s))
+}
+
+func upper(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return str(strings.ToUpper(args[0].(*object.String).Value))
+}
+
+func lower(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return str(strings.ToLower(args[0].(*object.String).Value))
+}
+
+func contains(args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return object.AsBool(strings.Contains(args[0].(*object.String).Value, args[1].(*object.String).Value))
+}
+
+// index returns the character index of the first occurrence of the second
+// argument, or -1.
+func index(args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	i := strings.Index(s, args[1].(*object.String).Value)
+	if i < 0 {
+		return object.AsInt(-1)
+	}
+	return object.AsInt(int64(utf8.RuneCountInString(s[:i])))
+}
+
+func replace(alloc object.Allocator, args ...object.Object) object.Object {
+	err := checkArgs(args, 3, object.OBJ_STRING, object.OBJ_STRING, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	s, from, to := args[0].(*object.String).Value, args[1].(*object.String).Value, args[2].(*object.String).Value
+	size := object.StringSize(len(s)) + int64(strings.Count(s, from))*int64(len(to)-len(from))
+	if err := alloc(size); err != nil {
+		return err
+	}
+	return str(strings.ReplaceAll(s, from, to))
+}
+
+func startsWith(args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return object.AsBool(strings.HasPrefix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+}
+
+func endsWith(args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return object.AsBool(strings.HasSuffix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+}
+
+// maxRepeat bounds the strings repeat and padding build, so a huge count
+// fails with an error even when the evaluation has no memory limit.
+const maxRepeat = 1 << 30
+
+func repeat(alloc object.Allocator, args ...object.Object) object.Object {
+	if err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_INTEGER); err != nil {
+		return err
+	}
+
+	s, count := args[0].(*object.String).Value, args[1].(*object.Integer).Value
+	if count < 0 {
+		return argError(1, "negative count %d", count)
+	}
+	if len(s) > 0 && count > maxRepeat/int64(len(s)) {
+		return argError(1, "count %d too large", count)
+	}
+	if err := alloc(object.StringSize(len(s) * int(count))); err != nil {
+		return err
+	}
+	return str(strings.Repeat(s, int(count)))
+}
+
+// padLeft pads the string to the given width in characters by prepending
+// the optional third argument, a space by default.
+func padLeft(alloc object.Allocator, args ...object.Object) object.Object {
+	return pad(alloc, args, true)
+}
+
+// padRight is like padLeft but appends the padding.
+func padRight(alloc object.Allocator, args ...object.Object) object.Object {
+	return pad(alloc, args, false)
+}
+
+func pad(alloc object.Allocator, args []object.Object, left bool) object.Object {
+	err := checkArgs(args, 2, object.OBJ_STRING, object.OBJ_INTEGER, object.OBJ_STRING)
+	if err != nil {
+		return err
+	}
+
+	s, width := args[0].(*object.String).Value, args[1].(*object.Integer).Value
+	padding := []rune(" ")
+	if len(args) == 3 {
+		padding = []rune(args[2].(*object.String).Value)
+		if len(padding) == 0 {
+			return argError(2, "empty padding")
+		}
+	}
+	if width > maxRepeat {
+		return argError(1, "width %d too large", width)
+	}
+
+	missing := max(int(width)-utf8.RuneCountInString(s), 0)
+	// the padding repeats whole, then as much of it as still fits
+	size := len(s) + missing/len(padding)*len(string(padding)) + len(string(padding[:missing%len(padding)]))
+	if err := alloc(object.StringSize(size)); err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	out.Grow(size)
+	if !left {
+		out.WriteString(s)
+	}
+	for i := range missing {
+		out.WriteRune(padding[i%len(padding)])
+	}
+	if left {
+		out.WriteString(s)
+	}
+	return str(out.String())
+}
+
+func length(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+	return object.AsInt(int64(utf8.RuneCountInString(args[0].(*object.String).Value)))
+}
+
+func chars(alloc object.Allocator, args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	n := utf8.RuneCountInString(s)
+	if err := alloc(object.ArraySize(n)); err != nil {
+		return err
+	}
+	elements := make([]object.Object, 0, n)
+	for _, r := range s {
+		c := string(r)
+		if err := alloc(object.StringSize(len(c))); err != nil {
+			return err
+		}
+		elements = append(elements, str(c))
+	}
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,60 @@
+package std_test
+
+import "testing"
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`strings.split("a,b,,c", ",")`, []any{"a", "b", "", "c"}},
+		{`strings.split("abc", "")`, []any{"a", "b", "c"}},
+		{`strings.join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`strings.join([], "-")`, ""},
+		{`strings.join(["a", 1], "-")`, "argument 1: element 1 is INTEGER, want STRING"},
+		{`strings.trim("  hi \n")`, "hi"},
+		{`strings.trim("--hi-", "-")`, "hi"},
+		{`strings.upper("héllo")`, "HÉLLO"},
+		{`strings.lower("HeLLo")`, "hello"},
+		{`strings.contains("monkey", "key")`, true},
+		{`strings.contains("monkey", "ape")`, false},
+		{`strings.index("héllo", "l")`, 2},
+		{`strings.index("hello", "z")`, -1},
+		{`strings.replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`strings.starts_with("monkey", "mon")`, true},
+		{`strings.ends_with("monkey", "mon")`, false},
+		{`strings.repeat("ab", 3)`, "ababab"},
+		{`strings.repeat("ab", 0)`, ""},
+		{`strings.repeat("ab", -1)`, "argument 2: negative count -1"},
+		{`strings.repeat("ab", 9223372036854775807)`, "argument 2: count 9223372036854775807 too large"},
+		{`strings.pad_left("7", 3)`, "  7"},
+		{`strings.pad_left("7", 3, "0")`, "007"},
+		{`strings.pad_right("ab", 7, "xy")`, "abxyxyx"},
+		{`strings.pad_right("héllo", 6, ".")`, "héllo."},
+		{`strings.pad_left("long", 2)`, "long"},
+		{`strings.pad_left("a", 3, "")`, "argument 3: empty padding"},
+		{`strings.len("héllo")`, 5},
+		{`strings.len("")`, 0},
+		{`strings.chars("日本")`, []any{"日", "本"}},
+		{`strings.upper()`, "wrong number of arguments: want=1, got=0"},
+		{`strings.split("a")`, "wrong number of arguments: want=2, got=1"},
+		{`strings.pad_left("a")`, "wrong number of arguments: want 2 to 3, got=1"},
+		{`strings.upper(1)`, "argument 1: want STRING, got INTEGER"},
+		{`strings.repeat("a", "b")`, "argument 2: want INTEGER, got STRING"},
+		{`strings.missing`, "module strings has no member missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, `import "strings"; `+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestStringsModule(t *testing.T) {
+	testObject(t, "alias", testEval(t, `import "strings" as s; s.upper("x")`), "X")
+
+	upper := testEval(t, `import "strings"; strings.upper`)
+	if upper.Inspect() != "builtin function strings.upper" {
+		t.Errorf("builtins should be named after the module, got=%q", upper.Inspect())
+	}
+}
@@ -0,0 +1,114 @@
+package std
+
+import (
+	"monkey/object"
+	"time"
+)
+
+var timeModule = NewTime(time.Now)
+
+// NewTime creates a time module whose now function reads clock, so hosts
+// can make scripts see a fixed or simulated time.
+func NewTime(clock func() time.Time) *object.Module {
+	mod := newModule("time", map[string]object.BuiltinFunction{
+		"now": func(args ...object.Object) object.Object {
+			if err := argCount(args, 0); err != nil {
+				return err
+			}
+			return &object.Time{Value: clock()}
+		},
+		"parse":    parseTime,
+		"format":   formatTime,
+		"date":     date,
+		"unix":     unix,
+		"duration": duration,
+	})
+
+	constants := map[string]object.Object{
+		"NANOSECOND":  &object.Duration{Value: time.Nanosecond},
+		"MICROSECOND": &object.Duration{Value: time.Microsecond},
+		"MILLISECOND": &object.Duration{Value: time.Millisecond},
+		"SECOND":      &object.Duration{Value: time.Second},
+		"MINUTE":      &object.Duration{Value: time.Minute},
+		"HOUR":        &object.Duration{Value: time.Hour},
+		"RFC3339":     str(time.RFC3339),
+		"DATE_TIME":   str(time.DateTime),
+		"DATE_ONLY":   str(time.DateOnly),
+		"TIME_ONLY":   str(time.TimeOnly),
+	}
+	for name, val := range constants {
+		mod.Env.Declare(name, val, true)
+	}
+	return mod
+}
+
+// parseTime reads a time written in a Go layout, RFC3339 by default.
+// Times without a zone are taken to be UTC.
+func parseTime(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	layout := time.RFC3339
+	if len(args) == 2 {
+		layout = args[1].(*object.String).Value
+	}
+
+	t, err := time.Parse(layout, args[0].(*object.String).Value)
+	if err != nil {
+		return object.FormatError("%s", err)
+	}
+	return &object.Time{Value: t}
+}
+
+// formatTime writes a time in a Go layout, RFC3339 by default.
+func formatTime(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_TIME, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	layout := time.RFC3339
+	if len(args) == 2 {
+		layout = args[1].(*object.String).Value
+	}
+	return str(args[0].(*object.Time).Value.Format(layout))
+}
+
+// date builds a UTC time from year, month and day, optionally followed by
+// hour, minute and second. Out of range values are normalised, so
+// date(2024, 1, 32) is February 1st.
+func date(args ...object.Object) object.Object {
+	err := checkArgs(args, 3, object.OBJ_INTEGER, object.OBJ_INTEGER, object.OBJ_INTEGER,
+		object.OBJ_INTEGER, object.OBJ_INTEGER, object.OBJ_INTEGER)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]int, 6)
+	for i, arg := range args {
+		fields[i] = int(intValue(arg))
+	}
+	return &object.Time{Value: time.Date(fields[0], time.Month(fields[1]), fields[2],
+		fields[3], fields[4], fields[5], 0, time.UTC)}
+}
+
+// unix returns the UTC time a number of seconds after the Unix epoch.
+func unix(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_INTEGER); err != nil {
+		return err
+	}
+	return &object.Time{Value: time.Unix(intValue(args[0]), 0).UTC()}
+}
+
+// duration parses a duration such as "1h30m" or "-250ms".
+func duration(args ...object.Object) object.Object {
+	if err := checkArgs(args, 1, object.OBJ_STRING); err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(args[0].(*object.String).Value)
+	if err != nil {
+		return object.FormatError("%s", err)
+	}
+	return &object.Duration{Value: d}
+}
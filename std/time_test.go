@@ -0,0 +1,67 @@
+package std_test
+
+import (
+	"monkey/object"
+	"monkey/std"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	clock := func() time.Time {
+		return time.Date(2024, time.February, 28, 22, 30, 0, 0, time.UTC)
+	}
+	imports := map[string]*object.Module{"time": std.NewTime(clock)}
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`time.format(time.now())`, "2024-02-28T22:30:00Z"},
+		{`time.format(time.now() + 2 * time.HOUR, time.DATE_ONLY)`, "2024-02-29"},
+		{`time.format(time.now() + time.duration("49h"), "Jan 2 2006")`, "Mar 1 2024"},
+		{`time.format(time.now() - 30 * time.MINUTE, time.DATE_TIME)`, "2024-02-28 22:00:00"},
+		{`time.now().weekday`, "Wednesday"},
+		{`time.now().year`, 2024},
+		{`time.now().month`, 2},
+		{`time.now().day`, 28},
+		{`time.now().hour`, 22},
+		{`time.now().yearday`, 59},
+		{`time.parse("2024-03-01", time.DATE_ONLY) - time.now()`, "25h30m0s"},
+		{`(time.parse("2024-03-01", time.DATE_ONLY) - time.now()).minutes`, 1530.0},
+		{`(time.HOUR / 4).milliseconds`, 900000},
+		{`time.parse("2024-02-28T23:30:00+01:00") == time.now()`, true},
+		{`time.parse("2024-01-01", time.DATE_ONLY) < time.now()`, true},
+		{`time.parse("2024-01-01", time.DATE_ONLY) > time.now()`, false},
+		{`time.date(2024, 1, 32) == time.parse("2024-02-01", time.DATE_ONLY)`, true},
+		{`time.format(time.date(2024, 12, 31, 23, 59, 59))`, "2024-12-31T23:59:59Z"},
+		{`time.unix(86400).day`, 2},
+		{`time.now().unix - time.now().unix`, 0},
+		{`time.parse("yesterday", time.DATE_ONLY)`,
+			`parsing time "yesterday" as "2006-01-02": cannot parse "yesterday" as "2006"`},
+		{`time.duration("soon")`, `time: invalid duration "soon"`},
+		{`time.format("2024")`, "argument 1: want TIME, got STRING"},
+		{`time.date(2024)`, "wrong number of arguments: want 3 to 6, got=1"},
+		{`time.now(1)`, "wrong number of arguments: want=0, got=1"},
+		{`time.now().century`, "TIME has no member century"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalImports(t, `import "time"; `+tt.input, imports)
+		if d, ok := evaluated.(*object.Duration); ok {
+			evaluated = &object.String{Value: d.Inspect()}
+		}
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestTimeNow(t *testing.T) {
+	before := time.Now()
+	now, ok := testEval(t, `import "time"; time.now()`).(*object.Time)
+	if !ok {
+		t.Fatalf("time.now() should return a TIME")
+	}
+	if now.Value.Before(before) || now.Value.After(time.Now()) {
+		t.Errorf("time.now() should read the real clock by default, got=%s", now.Inspect())
+	}
+}
@@ -6,6 +6,9 @@ const (
 
 	IDENTIFIER = "IDENTIFIER"
 	INT        = "INT"
+	FLOAT      = "FLOAT"
+	STRING     = "STRING"
+	COMMENT    = "COMMENT"
 
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -22,17 +25,26 @@ const (
 
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
 
-	LPAREN = "("
-	RPAREN = ")"
-	LCURLY = "{"
-	RCURLY = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LCURLY   = "{"
+	RCURLY   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
 
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	IF       = "if"
 	ELSE     = "else"
 	RETURN   = "return"
+	TRY      = "try"
+	CATCH    = "catch"
+	FINALLY  = "finally"
+	IMPORT   = "import"
 
 	TRUE  = "true"
 	FALSE = "false"
@@ -0,0 +1,635 @@
+package types
+
+import (
+	"fmt"
+	"maps"
+	"monkey/ast"
+	"monkey/token"
+	"slices"
+)
+
+// Span is the part of the source an error refers to, from the start of its
+// first token to the end of its last one.
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// Error is a type error in the expression at Span.
+type Error struct {
+	Span Span
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Span.Start, e.Msg)
+}
+
+// Env holds the type schemes of the names in scope. It carries over from
+// one call to Infer to the next, so a REPL can infer each line in the
+// context of the previous ones.
+type Env struct {
+	vars map[string]*Scheme
+	next int
+}
+
+// NewEnv returns an environment holding the builtins that have a type in
+// the strict profile. Builtins taking a variable number of arguments are
+// typed at the arity listed below.
+func NewEnv() *Env {
+	env := &Env{vars: make(map[string]*Scheme)}
+
+	a, b := &Var{ID: 0}, &Var{ID: 1}
+	fn := func(result Type, params ...Type) Type { return Function(params, result) }
+	builtins := map[string]Type{
+		"puts":    fn(Null, a),
+		"throw":   fn(b, a),
+		"map":     fn(Array(b), Array(a), fn(b, a)),
+		"filter":  fn(Array(a), Array(a), fn(Bool, a)),
+		"reduce":  fn(b, Array(a), fn(b, b, a), b),
+		"each":    fn(Null, Array(a), fn(b, a)),
+		"any":     fn(Bool, Array(a), fn(Bool, a)),
+		"all":     fn(Bool, Array(a), fn(Bool, a)),
+		"find":    fn(a, Array(a), fn(Bool, a)),
+		"sort":    fn(Array(a), Array(a)),
+		"reverse": fn(Array(a), Array(a)),
+		"range":   fn(Array(Int), Int),
+		"keys":    fn(Array(a), Hash(a, b)),
+		"values":  fn(Array(b), Hash(a, b)),
+	}
+	for name, t := range builtins {
+		env.vars[name] = &Scheme{Vars: t.freeVars(nil), Type: t}
+	}
+	env.next = 2
+	return env
+}
+
+// Infer infers the type of each statement of program in turn, binding the
+// names its top-level lets declare in env, and returns the principal type
+// of the last one, but for arithmetic operands defaulting to int as
+// described in the package documentation. Inference stops at the first type
+// error.
+func (env *Env) Infer(program *ast.Program) (Type, error) {
+	in := &inferencer{env: env, assigned: make(map[string]bool)}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if ident, ok := assign.Target.(*ast.Identifier); ok {
+				in.assigned[ident.Value] = true
+			}
+		}
+		return true
+	})
+
+	var result Type = Null
+	for i, stmt := range program.Statements {
+		vars := maps.Clone(env.vars)
+		s, t, err := in.inferStmt(vars, stmt, i == len(program.Statements)-1)
+		if err != nil {
+			return nil, err
+		}
+		env.vars = applyVars(s, vars)
+		result = t.apply(s)
+	}
+	return normalize(result)[0], nil
+}
+
+// Clone returns a copy of env, which infers independently of it.
+func (env *Env) Clone() *Env {
+	return &Env{vars: maps.Clone(env.vars), next: env.next}
+}
+
+// Delete removes name from env, for names whose type is no longer known.
+func (env *Env) Delete(name string) {
+	delete(env.vars, name)
+}
+
+// Lookup returns the principal type of name, which is also the type of the
+// values it is bound to if it is generalised.
+func (env *Env) Lookup(name string) (Type, bool) {
+	sc, ok := env.vars[name]
+	if !ok {
+		return nil, false
+	}
+	return normalize(sc.Type)[0], true
+}
+
+type inferencer struct {
+	env *Env
+	// assigned holds the names that are assigned to somewhere in the
+	// program, which may be rebound to values of another type
+	assigned map[string]bool
+}
+
+// result is the name the result type of the enclosing function literal is
+// bound to, so that substitutions are applied to it like to any other
+// variable. As a keyword, it cannot clash with an identifier.
+const resultVar = "return"
+
+func (in *inferencer) fresh() *Var {
+	v := &Var{ID: in.env.next}
+	in.env.next++
+	return v
+}
+
+func applyVars(s Subst, vars map[string]*Scheme) map[string]*Scheme {
+	out := make(map[string]*Scheme, len(vars))
+	for name, sc := range vars {
+		out[name] = sc.apply(s)
+	}
+	return out
+}
+
+func (in *inferencer) instantiate(sc *Scheme) Type {
+	s := make(Subst, len(sc.Vars))
+	for _, v := range sc.Vars {
+		s[v] = in.fresh()
+	}
+	return sc.Type.apply(s)
+}
+
+// generalize quantifies t over the variables not free in vars.
+func generalize(vars map[string]*Scheme, t Type) *Scheme {
+	var bound []int
+	for _, sc := range vars {
+		bound = sc.freeVars(bound)
+	}
+
+	var free []int
+	for _, v := range t.freeVars(nil) {
+		if !slices.Contains(bound, v) {
+			free = append(free, v)
+		}
+	}
+	return &Scheme{Vars: free, Type: t}
+}
+
+// unify unifies t and u, attributing a failure to node.
+func (in *inferencer) unify(node ast.Node, t, u Type) (Subst, error) {
+	s, err := unify(t, u)
+	if err != nil {
+		return nil, &Error{Span: span(node), Msg: err.Error()}
+	}
+	return s, nil
+}
+
+func (in *inferencer) errorf(node ast.Node, format string, args ...any) error {
+	return &Error{Span: span(node), Msg: fmt.Sprintf(format, args...)}
+}
+
+// inferStmts infers the type of the value of stmts, binding the names they
+// declare in vars. The value is the one of the last statement, and used
+// tells whether it is used.
+func (in *inferencer) inferStmts(vars map[string]*Scheme, stmts []ast.Statement, used bool) (Subst, Type, error) {
+	s := Subst{}
+	var result Type = Null
+
+	vars = maps.Clone(vars)
+	for i, stmt := range stmts {
+		s1, t, err := in.inferStmt(vars, stmt, used && i == len(stmts)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s1.compose(s)
+		for name, sc := range vars {
+			vars[name] = sc.apply(s1)
+		}
+		result = t
+	}
+	return s, result.apply(s), nil
+}
+
+// inferStmt infers the type of stmt, adding the name a let declares to
+// vars. used tells whether the value of stmt is used.
+func (in *inferencer) inferStmt(vars map[string]*Scheme, stmt ast.Statement, used bool) (Subst, Type, error) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return in.inferLet(vars, stmt)
+	case *ast.ReturnStatement:
+		fn, ok := vars[resultVar]
+		if !ok {
+			return nil, nil, in.errorf(stmt, "return outside of a function")
+		}
+		s, t, err := in.infer(vars, stmt.ReturnValue)
+		if err != nil {
+			return nil, nil, err
+		}
+		s2, err := in.unify(stmt.ReturnValue, fn.Type.apply(s), t)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the statements after a return are never reached, so the block
+		// can be given any type
+		return s2.compose(s), in.fresh(), nil
+	case *ast.ExpressionStatement:
+		if expr, ok := stmt.Expression.(*ast.IfExpression); ok {
+			return in.inferIf(vars, expr, used)
+		}
+		return in.infer(vars, stmt.Expression)
+	case *ast.BlockStatement:
+		return in.inferStmts(vars, stmt.Statements, used)
+	default:
+		return nil, nil, in.errorf(stmt, "cannot infer the type of %s", stmt)
+	}
+}
+
+// inferLet binds the name of a let, generalising functions. The name is
+// in scope in the function body, so functions can be recursive. Other
+// values, and functions bound to names that are assigned to, are not
+// generalised, since assignments could otherwise change their type.
+func (in *inferencer) inferLet(vars map[string]*Scheme, let *ast.LetStatement) (Subst, Type, error) {
+	fn, isFunction := let.Value.(*ast.FunctionLiteral)
+
+	inner := vars
+	var self Type
+	if isFunction {
+		self = in.fresh()
+		inner = maps.Clone(vars)
+		inner[let.Name.Value] = &Scheme{Type: self}
+	}
+
+	s, t, err := in.infer(inner, let.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isFunction {
+		s2, err := in.unify(fn, self.apply(s), t)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s2.compose(s)
+		t = t.apply(s2)
+	}
+
+	delete(vars, let.Name.Value)
+	for name, sc := range vars {
+		vars[name] = sc.apply(s)
+	}
+	if isFunction && (let.IsConst() || !in.assigned[let.Name.Value]) {
+		vars[let.Name.Value] = generalize(vars, t)
+	} else {
+		vars[let.Name.Value] = &Scheme{Type: t}
+	}
+	return s, Null, nil
+}
+
+func (in *inferencer) infer(vars map[string]*Scheme, expr ast.Expression) (Subst, Type, error) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return Subst{}, Int, nil
+	case *ast.FloatLiteral:
+		return Subst{}, Float, nil
+	case *ast.StringLiteral:
+		return Subst{}, String, nil
+	case *ast.Boolean:
+		return Subst{}, Bool, nil
+	case *ast.Identifier:
+		sc, ok := vars[expr.Value]
+		if !ok {
+			return nil, nil, in.errorf(expr, "undefined: %s", expr.Value)
+		}
+		return Subst{}, in.instantiate(sc), nil
+	case *ast.PrefixExpression:
+		return in.inferPrefix(vars, expr)
+	case *ast.InfixExpression:
+		return in.inferInfix(vars, expr)
+	case *ast.IfExpression:
+		return in.inferIf(vars, expr, true)
+	case *ast.FunctionLiteral:
+		return in.inferFunction(vars, expr)
+	case *ast.CallExpression:
+		return in.inferCall(vars, expr)
+	case *ast.AssignExpression:
+		return in.inferAssign(vars, expr)
+	case *ast.ArrayLiteral:
+		return in.inferElements(vars, expr, expr.Elements, Array)
+	case *ast.HashLiteral:
+		return in.inferHash(vars, expr)
+	case *ast.IndexExpression:
+		return in.inferIndex(vars, expr)
+	case *ast.TryExpression:
+		return in.inferTry(vars, expr)
+	default:
+		return nil, nil, in.errorf(expr, "cannot infer the type of %s", expr)
+	}
+}
+
+// inferAll infers the types of exprs from left to right, applying the
+// substitution found for each to the types of the ones before.
+func (in *inferencer) inferAll(vars map[string]*Scheme, exprs []ast.Expression) (Subst, []Type, error) {
+	s := Subst{}
+	types := make([]Type, len(exprs))
+
+	for i, expr := range exprs {
+		s1, t, err := in.infer(applyVars(s, vars), expr)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s1.compose(s)
+		types[i] = t
+	}
+
+	for i := range types {
+		types[i] = types[i].apply(s)
+	}
+	return s, types, nil
+}
+
+func (in *inferencer) inferPrefix(vars map[string]*Scheme, expr *ast.PrefixExpression) (Subst, Type, error) {
+	s, t, err := in.infer(vars, expr.Right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expr.Operator == "!" {
+		s2, err := in.unify(expr.Right, Bool, t)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s2.compose(s), Bool, nil
+	}
+
+	s2, t, err := in.operand(expr, t, CON_INT, CON_FLOAT)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s2.compose(s), t, nil
+}
+
+// operand checks that operator expr applies to values of type t, which
+// has to be one of the types named by cons. Without a type class to
+// express "any number", a type variable defaults to int. The types
+// inferred for such operands are therefore not principal: fn(a, b) { a + b }
+// is typed fn(int, int): int, and applying it to floats or strings is
+// rejected although it would run.
+func (in *inferencer) operand(expr ast.Node, t Type, cons ...string) (Subst, Type, error) {
+	switch t := t.(type) {
+	case *Var:
+		return Subst{t.ID: Int}, Int, nil
+	case *Con:
+		if slices.Contains(cons, t.Name) {
+			return Subst{}, t, nil
+		}
+	}
+
+	var op string
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		op = expr.Operator
+	case *ast.InfixExpression:
+		op = expr.Operator
+	}
+	return nil, nil, in.errorf(expr, "operator %s not defined on %s", op, normalize(t)[0])
+}
+
+func (in *inferencer) inferInfix(vars map[string]*Scheme, expr *ast.InfixExpression) (Subst, Type, error) {
+	s, types, err := in.inferAll(vars, []ast.Expression{expr.Left, expr.Right})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// both operands have the same type, the language has no implicit
+	// conversions in the strict profile
+	s2, err := in.unify(expr, types[0], types[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s2.compose(s)
+	t := types[0].apply(s2)
+
+	var cons []string
+	switch expr.Operator {
+	case "==", "!=":
+		return s, Bool, nil
+	case "+":
+		cons = []string{CON_INT, CON_FLOAT, CON_STRING}
+	default:
+		cons = []string{CON_INT, CON_FLOAT}
+	}
+
+	s3, t, err := in.operand(expr, t, cons...)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s3.compose(s)
+
+	if expr.Operator == "<" || expr.Operator == ">" {
+		return s, Bool, nil
+	}
+	return s, t, nil
+}
+
+// inferIf infers the type of an if expression, whose value used tells
+// whether it is used.
+func (in *inferencer) inferIf(vars map[string]*Scheme, expr *ast.IfExpression, used bool) (Subst, Type, error) {
+	s, t, err := in.infer(vars, expr.Condition)
+	if err != nil {
+		return nil, nil, err
+	}
+	s2, err := in.unify(expr.Condition, Bool, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s2.compose(s)
+
+	s3, cons, err := in.inferStmts(applyVars(s, vars), expr.Consequence.Statements, used)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s3.compose(s)
+	if expr.Alternative == nil {
+		if !used {
+			return s, Null, nil
+		}
+		// the value is null when the condition does not hold, so the
+		// consequence has to be null too
+		s4, err := in.unify(expr, Null, cons)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s4.compose(s), Null, nil
+	}
+
+	s4, alt, err := in.inferStmts(applyVars(s, vars), expr.Alternative.Statements, used)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s4.compose(s)
+
+	s5, err := in.unify(expr, cons.apply(s4), alt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s5.compose(s), alt.apply(s5), nil
+}
+
+func (in *inferencer) inferFunction(vars map[string]*Scheme, fn *ast.FunctionLiteral) (Subst, Type, error) {
+	inner := maps.Clone(vars)
+	params := make([]Type, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = in.fresh()
+		inner[param.Value] = &Scheme{Type: params[i]}
+	}
+
+	ret := in.fresh()
+	inner[resultVar] = &Scheme{Type: ret}
+	s, body, err := in.inferStmts(inner, fn.Body.Statements, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s2, err := in.unify(fn, ret.apply(s), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s2.compose(s)
+	return s, Function(params, ret).apply(s), nil
+}
+
+func (in *inferencer) inferCall(vars map[string]*Scheme, call *ast.CallExpression) (Subst, Type, error) {
+	s, types, err := in.inferAll(vars, append([]ast.Expression{call.Func}, call.Args...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := in.fresh()
+	s2, err := in.unify(call, types[0], Function(types[1:], result))
+	if err != nil {
+		return nil, nil, err
+	}
+	return s2.compose(s), result.apply(s2), nil
+}
+
+func (in *inferencer) inferAssign(vars map[string]*Scheme, expr *ast.AssignExpression) (Subst, Type, error) {
+	switch expr.Target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		return nil, nil, in.errorf(expr.Target, "cannot infer the type of %s", expr.Target)
+	}
+
+	// a generalised name, bound by an earlier call to Infer, stands for
+	// values of every instance of its type, which no single value is
+	if ident, ok := expr.Target.(*ast.Identifier); ok {
+		if sc, ok := vars[ident.Value]; ok && len(sc.Vars) > 0 {
+			return nil, nil, in.errorf(expr.Target, "cannot assign to %s of polymorphic type %s",
+				ident.Value, normalize(sc.Type)[0])
+		}
+	}
+
+	s, types, err := in.inferAll(vars, []ast.Expression{expr.Target, expr.Value})
+	if err != nil {
+		return nil, nil, err
+	}
+	s2, err := in.unify(expr, types[0], types[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return s2.compose(s), types[1].apply(s2), nil
+}
+
+// inferElements infers the type of a literal whose elements all have the
+// same type.
+func (in *inferencer) inferElements(
+	vars map[string]*Scheme,
+	lit ast.Expression,
+	elements []ast.Expression,
+	con func(Type) *Con,
+) (Subst, Type, error) {
+	s, types, err := in.inferAll(vars, elements)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var elem Type = in.fresh()
+	for i, t := range types {
+		s2, err := in.unify(elements[i], elem, t.apply(s))
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s2.compose(s)
+		elem = elem.apply(s2)
+	}
+	return s, con(elem.apply(s)), nil
+}
+
+func (in *inferencer) inferHash(vars map[string]*Scheme, hash *ast.HashLiteral) (Subst, Type, error) {
+	keys := make([]ast.Expression, len(hash.Pairs))
+	values := make([]ast.Expression, len(hash.Pairs))
+	for i, pair := range hash.Pairs {
+		keys[i], values[i] = pair.Key, pair.Value
+	}
+
+	s, keyArray, err := in.inferElements(vars, hash, keys, Array)
+	if err != nil {
+		return nil, nil, err
+	}
+	s2, valueArray, err := in.inferElements(applyVars(s, vars), hash, values, Array)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := keyArray.(*Con).Args[0].apply(s2)
+	value := valueArray.(*Con).Args[0]
+	return s2.compose(s), Hash(key, value), nil
+}
+
+// inferIndex types indexing a hash by its key type, and indexing anything
+// else as an array indexed by integers.
+func (in *inferencer) inferIndex(vars map[string]*Scheme, expr *ast.IndexExpression) (Subst, Type, error) {
+	s, types, err := in.inferAll(vars, []ast.Expression{expr.Left, expr.Index})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	elem := in.fresh()
+	container, index := Array(elem), Type(Int)
+	if c, ok := types[0].(*Con); ok && c.Name == CON_HASH {
+		container, index = Hash(in.fresh(), elem), c.Args[0]
+	}
+
+	s2, err := in.unify(expr.Left, container, types[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	s3, err := in.unify(expr.Index, index.apply(s2), types[1].apply(s2))
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s3.compose(s2).compose(s)
+	return s, elem.apply(s), nil
+}
+
+// inferTry gives a try expression the type of its block, which the catch
+// block has to share. Thrown values can be of any type.
+func (in *inferencer) inferTry(vars map[string]*Scheme, expr *ast.TryExpression) (Subst, Type, error) {
+	s, t, err := in.inferStmts(vars, expr.Block.Statements, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expr.Catch != nil {
+		inner := applyVars(s, vars)
+		if expr.Param != nil {
+			inner[expr.Param.Value] = &Scheme{Type: in.fresh()}
+		}
+		s2, catch, err := in.inferStmts(inner, expr.Catch.Statements, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		s3, err := in.unify(expr, t.apply(s2), catch)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s3.compose(s2).compose(s)
+		t = catch.apply(s3)
+	}
+
+	if expr.Finally != nil {
+		s2, _, err := in.inferStmts(applyVars(s, vars), expr.Finally.Statements, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s2.compose(s)
+		t = t.apply(s2)
+	}
+	return s, t, nil
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"monkey/ast"
+	"monkey/token"
+)
+
+// span finds the extent of node from the tokens recorded in the syntax
+// tree. Closing delimiters are not recorded, so the span of f(x) ends
+// after the x.
+func span(node ast.Node) Span {
+	var sp Span
+	ast.Inspect(node, func(n ast.Node) bool {
+		tok, ok := nodeToken(n)
+		if !ok || !tok.Pos.IsValid() {
+			return true
+		}
+
+		end := tok.Pos
+		end.Column += len(tok.Literal)
+		if _, ok := n.(*ast.StringLiteral); ok {
+			end.Column += 2
+		}
+
+		if !sp.Start.IsValid() || tok.Pos.Before(sp.Start) {
+			sp.Start = tok.Pos
+		}
+		if sp.End.Before(end) {
+			sp.End = end
+		}
+		return true
+	})
+	return sp
+}
+
+func nodeToken(node ast.Node) (token.Token, bool) {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return node.Token, true
+	case *ast.AssignExpression:
+		return node.Token, true
+	case *ast.ImportStatement:
+		return node.Token, true
+	case *ast.Identifier:
+		return node.Token, true
+	case *ast.ReturnStatement:
+		return node.Token, true
+	case *ast.ExpressionStatement:
+		return node.Token, true
+	case *ast.IntegerLiteral:
+		return node.Token, true
+	case *ast.FloatLiteral:
+		return node.Token, true
+	case *ast.StringLiteral:
+		return node.Token, true
+	case *ast.PrefixExpression:
+		return node.Token, true
+	case *ast.InfixExpression:
+		return node.Token, true
+	case *ast.Boolean:
+		return node.Token, true
+	case *ast.IfExpression:
+		return node.Token, true
+	case *ast.BlockStatement:
+		return node.Token, true
+	case *ast.FunctionLiteral:
+		return node.Token, true
+	case *ast.CallExpression:
+		return node.Token, true
+	case *ast.TryExpression:
+		return node.Token, true
+	case *ast.ArrayLiteral:
+		return node.Token, true
+	case *ast.IndexExpression:
+		return node.Token, true
+	case *ast.DotExpression:
+		return node.Token, true
+	case *ast.HashLiteral:
+		return node.Token, true
+	default:
+		return token.Token{}, false
+	}
+}
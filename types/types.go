@@ -0,0 +1,194 @@
+// Package types infers the types of programs written in the strict profile
+// of the language, in which every expression has a single static type and
+// no annotations are needed. It implements Hindley-Milner type inference
+// with Algorithm W: functions bound with let are generalised, so
+// let id = fn(x) { x } can be applied to values of any type.
+//
+// The operands of arithmetic operators can be ints or floats, and of + also
+// strings. Without type classes to express that, operands whose type is
+// not known otherwise are taken to be ints, so types involving them are not
+// principal: fn(a, b) { a + b } is typed fn(int, int): int, although it
+// also adds floats and strings.
+package types
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Type is a monotype, either a type variable or a type constructor applied
+// to argument types.
+type Type interface {
+	String() string
+	freeVars(vars []int) []int
+	apply(s Subst) Type
+}
+
+// Var is a type variable, standing for a type not determined yet.
+type Var struct {
+	ID int
+}
+
+// String names the variable with letters, a to z and then a1, b1 and so
+// on.
+func (v *Var) String() string {
+	name := string(rune('a' + v.ID%26))
+	if v.ID >= 26 {
+		name += strconv.Itoa(v.ID / 26)
+	}
+	return name
+}
+
+func (v *Var) freeVars(vars []int) []int {
+	if slices.Contains(vars, v.ID) {
+		return vars
+	}
+	return append(vars, v.ID)
+}
+
+func (v *Var) apply(s Subst) Type {
+	if t, ok := s[v.ID]; ok {
+		return t
+	}
+	return v
+}
+
+// The names of the type constructors.
+const (
+	CON_INT      = "int"
+	CON_FLOAT    = "float"
+	CON_STRING   = "string"
+	CON_BOOL     = "bool"
+	CON_NULL     = "null"
+	CON_ARRAY    = "array"
+	CON_HASH     = "hash"
+	CON_FUNCTION = "fn"
+)
+
+// Con is a type constructor applied to its arguments: the element type of
+// arrays, the key and value types of hashes, and the parameter types
+// followed by the result type of functions.
+type Con struct {
+	Name string
+	Args []Type
+}
+
+var (
+	Int    = &Con{Name: CON_INT}
+	Float  = &Con{Name: CON_FLOAT}
+	String = &Con{Name: CON_STRING}
+	Bool   = &Con{Name: CON_BOOL}
+	Null   = &Con{Name: CON_NULL}
+)
+
+func Array(elem Type) *Con {
+	return &Con{Name: CON_ARRAY, Args: []Type{elem}}
+}
+
+func Hash(key, value Type) *Con {
+	return &Con{Name: CON_HASH, Args: []Type{key, value}}
+}
+
+func Function(params []Type, result Type) *Con {
+	return &Con{Name: CON_FUNCTION, Args: append(slices.Clip(params), result)}
+}
+
+// String formats c the way type annotations are written.
+func (c *Con) String() string {
+	switch c.Name {
+	case CON_ARRAY:
+		return "[" + c.Args[0].String() + "]"
+	case CON_HASH:
+		return "{" + c.Args[0].String() + ": " + c.Args[1].String() + "}"
+	case CON_FUNCTION:
+		params := make([]string, len(c.Args)-1)
+		for i, p := range c.Args[:len(c.Args)-1] {
+			params[i] = p.String()
+		}
+		return "fn(" + strings.Join(params, ", ") + "): " + c.Args[len(c.Args)-1].String()
+	default:
+		return c.Name
+	}
+}
+
+func (c *Con) freeVars(vars []int) []int {
+	for _, arg := range c.Args {
+		vars = arg.freeVars(vars)
+	}
+	return vars
+}
+
+func (c *Con) apply(s Subst) Type {
+	if len(c.Args) == 0 || len(s) == 0 {
+		return c
+	}
+
+	args := make([]Type, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = arg.apply(s)
+	}
+	return &Con{Name: c.Name, Args: args}
+}
+
+// Scheme is a type quantified over some of its variables, such as the type
+// forall a. fn(a): a of the identity function.
+type Scheme struct {
+	Vars []int
+	Type Type
+}
+
+func (sc *Scheme) freeVars(vars []int) []int {
+	for _, v := range sc.Type.freeVars(nil) {
+		if !slices.Contains(sc.Vars, v) && !slices.Contains(vars, v) {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+func (sc *Scheme) apply(s Subst) *Scheme {
+	inner := make(Subst, len(s))
+	for v, t := range s {
+		if !slices.Contains(sc.Vars, v) {
+			inner[v] = t
+		}
+	}
+	return &Scheme{Vars: sc.Vars, Type: sc.Type.apply(inner)}
+}
+
+// Subst maps type variables to the types they stand for.
+type Subst map[int]Type
+
+// compose returns the substitution applying s2 and then s.
+func (s Subst) compose(s2 Subst) Subst {
+	out := make(Subst, len(s)+len(s2))
+	for v, t := range s2 {
+		out[v] = t.apply(s)
+	}
+	for v, t := range s {
+		if _, ok := out[v]; !ok {
+			out[v] = t
+		}
+	}
+	return out
+}
+
+// normalize renames the variables of types to a, b, c and so on in the
+// order they appear, which is how principal types are presented.
+func normalize(types ...Type) []Type {
+	s := Subst{}
+	for _, t := range types {
+		for _, v := range t.freeVars(nil) {
+			if _, ok := s[v]; !ok {
+				s[v] = &Var{ID: len(s)}
+			}
+		}
+	}
+
+	out := make([]Type, len(types))
+	for i, t := range types {
+		out[i] = t.apply(s)
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package types
+
+import (
+	"errors"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"testing"
+)
+
+func testInfer(t *testing.T, env *Env, input string) (Type, error) {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return env.Infer(program)
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`5`, "int"},
+		{`1.5 * 2.0`, "float"},
+		{`"a" + "b"`, "string"},
+		{`1 < 2`, "bool"},
+		{`!true`, "bool"},
+		{`fn(x) { x }`, "fn(a): a"},
+		{`fn(x, y) { x }`, "fn(a, b): a"},
+		{`fn(f, x) { f(f(x)) }`, "fn(fn(a): a, a): a"},
+		{`fn(f, g) { fn(x) { f(g(x)) } }`, "fn(fn(a): b, fn(c): a): fn(c): b"},
+		{`fn(a, b) { a + b }`, "fn(int, int): int"},
+		{`fn(a) { a + "!" }`, "fn(string): string"},
+		{`fn(x) { if (x) { 1 } else { 2 } }`, "fn(bool): int"},
+		{`if (true) { puts(1) }`, "null"},
+		// the value of an if without else is only constrained when used
+		{`fn(c) { let x = 1; if (c) { x = 2 }; x }`, "fn(bool): int"},
+		{`fn(c) { if (c) { 1 }; 2 }`, "fn(bool): int"},
+		{`try { 1 } finally { if (true) { 2 } }`, "int"},
+		{`let id = fn(x) { x }; [id(1), id(2)]`, "[int]"},
+		{`let id = fn(x) { x }; id(id)(true)`, "bool"},
+		{`let id = fn(x) { x }; let pair = fn(a, b) { [a] }; pair(id(1), id("s"))`, "[int]"},
+		{`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact`, "fn(int): int"},
+		{`fn(n) { if (n > 0) { return "pos" } "other" }`, "fn(int): string"},
+		{`[]`, "[a]"},
+		{`{"a": 1, "b": 2}`, "{string: int}"},
+		{`fn(xs) { xs[0] + 1 }`, "fn([int]): int"},
+		{`let h = {"a": 1}; h["a"]`, "int"},
+		{`fn(xs) { xs[0] }`, "fn([a]): a"},
+		{`map([1, 2], fn(x) { x > 1 })`, "[bool]"},
+		{`reduce([1, 2], fn(acc, x) { acc + x }, 0)`, "int"},
+		{`let x = 1; x = 2`, "int"},
+		// functions bound to names assigned to are not generalised
+		{`let f = fn(x) { x }; f = fn(x) { x + 1 }; f`, "fn(int): int"},
+		{`const id = fn(x) { x }; [id(1), id(2)]`, "[int]"},
+		{`try { 1 } catch (e) { 2 }`, "int"},
+		{`let compose = fn(f, g) { fn(x) { f(g(x)) } }; compose(fn(b) { if (b) { 1 } else { 0 } }, fn(s) { s == "a" })`,
+			"fn(string): int"},
+	}
+
+	for _, tt := range tests {
+		typ, err := testInfer(t, NewEnv(), tt.input)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("wrong type for %q, want=%s, got=%s", tt.input, tt.expected, typ)
+		}
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		end      token.Position
+	}{
+		{`1 + true`, "1:1: cannot unify int with bool", token.Position{Line: 1, Column: 9}},
+		{`"a" - "b"`, "1:1: operator - not defined on string", token.Position{Line: 1, Column: 10}},
+		{`-true`, "1:1: operator - not defined on bool", token.Position{Line: 1, Column: 6}},
+		{`if (1) { 2 }`, "1:5: cannot unify bool with int", token.Position{Line: 1, Column: 6}},
+		{`let g = fn(b) { if (b) { "s" } }; g(true) == g(false)`, "1:17: cannot unify null with string",
+			token.Position{Line: 1, Column: 29}},
+		{`if (true) { 1 }`, "1:1: cannot unify null with int", token.Position{Line: 1, Column: 14}},
+		{`if (true) { 1 } else { "a" }`, "1:1: cannot unify int with string", token.Position{Line: 1, Column: 27}},
+		{`let f = fn(x) { x + 1 }; f("a")`, "1:26: cannot unify fn(int): int with fn(string): a",
+			token.Position{Line: 1, Column: 31}},
+		{`let f = fn(x) { x }; f(1, 2)`, "1:22: cannot unify fn(a): a with fn(int, int): b",
+			token.Position{Line: 1, Column: 28}},
+		{`fn(x) { x(x) }`, "1:9: infinite type: a occurs in fn(a): b", token.Position{Line: 1, Column: 12}},
+		{`[1, "a"]`, `1:5: cannot unify int with string`, token.Position{Line: 1, Column: 8}},
+		{`let x = 1; x = "a"`, "1:12: cannot unify int with string", token.Position{Line: 1, Column: 19}},
+		{`let f = fn(x) { x }; f = fn(x) { x + 1 }; f("s")`, "1:43: cannot unify fn(int): int with fn(string): a",
+			token.Position{Line: 1, Column: 48}},
+		{`let id = fn(x) { x }; let f = fn(x) { x }; f = id; f(1); f("s")`, "1:58: cannot unify fn(int): int with fn(string): a",
+			token.Position{Line: 1, Column: 63}},
+		{`fn(x) { let y = x; y(1); y + 1 }`, "1:26: cannot unify fn(int): a with int",
+			token.Position{Line: 1, Column: 31}},
+		{`missing(1)`, "1:1: undefined: missing", token.Position{Line: 1, Column: 8}},
+		{`return 1`, "1:1: return outside of a function", token.Position{Line: 1, Column: 9}},
+		{`let h = {}; h.size`, "1:13: cannot infer the type of h.size", token.Position{Line: 1, Column: 19}},
+	}
+
+	for _, tt := range tests {
+		_, err := testInfer(t, NewEnv(), tt.input)
+		var typeErr *Error
+		if !errors.As(err, &typeErr) {
+			t.Errorf("expected a type error for %q, got=%v", tt.input, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, want=%q, got=%q", tt.input, tt.expected, err)
+		}
+		if typeErr.Span.End != tt.end {
+			t.Errorf("wrong span end for %q, want=%s, got=%s", tt.input, tt.end, typeErr.Span.End)
+		}
+	}
+}
+
+func TestInferSession(t *testing.T) {
+	env := NewEnv()
+
+	if _, err := testInfer(t, env, `let twice = fn(f, x) { f(f(x)) };`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := testInfer(t, env, `let n = twice(fn(x) { x * 2 }, 1);`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if typ, ok := env.Lookup("twice"); !ok || typ.String() != "fn(fn(a): a, a): a" {
+		t.Errorf("twice should stay polymorphic, got=%v", typ)
+	}
+	typ, err := testInfer(t, env, `twice(fn(s) { s + "!" }, "hi")`)
+	if err != nil || typ.String() != "string" {
+		t.Errorf("wrong type, got=%v (%v)", typ, err)
+	}
+
+	if _, err := testInfer(t, env, `n + "a"`); err == nil {
+		t.Errorf("n should be an int")
+	}
+
+	// a generalised function cannot be rebound on a later line
+	_, err = testInfer(t, env, `twice = fn(f, x) { x + 1 }`)
+	if err == nil || err.Error() != "1:1: cannot assign to twice of polymorphic type fn(fn(a): a, a): a" {
+		t.Errorf("assigning to twice should fail, got=%v", err)
+	}
+}
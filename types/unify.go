@@ -0,0 +1,50 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+)
+
+// unify returns the most general substitution making t and u equal.
+func unify(t, u Type) (Subst, error) {
+	switch t := t.(type) {
+	case *Var:
+		return bind(t, u)
+	case *Con:
+		if v, ok := u.(*Var); ok {
+			return bind(v, t)
+		}
+
+		c := u.(*Con)
+		if t.Name != c.Name || len(t.Args) != len(c.Args) {
+			return nil, mismatch(t, c)
+		}
+
+		s := Subst{}
+		for i := range t.Args {
+			s2, err := unify(t.Args[i].apply(s), c.Args[i].apply(s))
+			if err != nil {
+				return nil, mismatch(t.apply(s), c.apply(s))
+			}
+			s = s2.compose(s)
+		}
+		return s, nil
+	}
+	panic(fmt.Sprintf("unexpected type %T", t))
+}
+
+func bind(v *Var, t Type) (Subst, error) {
+	if u, ok := t.(*Var); ok && u.ID == v.ID {
+		return Subst{}, nil
+	}
+	if slices.Contains(t.freeVars(nil), v.ID) {
+		names := normalize(v, t)
+		return nil, fmt.Errorf("infinite type: %s occurs in %s", names[0], names[1])
+	}
+	return Subst{v.ID: t}, nil
+}
+
+func mismatch(t, u Type) error {
+	names := normalize(t, u)
+	return fmt.Errorf("cannot unify %s with %s", names[0], names[1])
+}